@@ -0,0 +1,305 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+// xmlText escapes the text content, quotes are left as they are
+var xmlText = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+/**
+* android resource names only allow letters, digits and underscores,
+* androidNames maps the sorted keys to unique names
+**/
+func androidNames(keys []string) map[string]string {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	res := make(map[string]string, len(sorted))
+	used := make(map[string]bool, len(sorted))
+	for _, key := range sorted {
+		var b strings.Builder
+		for _, r := range key {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+				b.WriteRune(r)
+			} else {
+				b.WriteByte('_')
+			}
+		}
+		name := b.String()
+		if name == "" || unicode.IsDigit(rune(name[0])) {
+			name = "_" + name
+		}
+		unique := name
+		for i := 2; used[unique]; i++ {
+			unique = name + "_" + strconv.Itoa(i)
+		}
+		used[unique] = true
+		res[key] = unique
+	}
+	return res
+}
+
+// androidQualifier returns the language qualifier of the values directory, values-pt-rBR or values-b+sr+Latn
+func androidQualifier(lang language.I18nLang) string {
+	tag := lang.Tag()
+	if tag.Script != "" || (tag.Region != "" && len(tag.Region) != 2) || tag.Variant != "" {
+		return "b+" + strings.ReplaceAll(tag.String(), "-", "+")
+	}
+	if tag.Region != "" {
+		return tag.Language + "-r" + tag.Region
+	}
+	return tag.Language
+}
+
+// androidLang parses the language qualifier of a values directory
+func androidLang(qualifier string) string {
+	if strings.HasPrefix(qualifier, "b+") {
+		return strings.ReplaceAll(strings.TrimPrefix(qualifier, "b+"), "+", "-")
+	}
+	parts := strings.Split(qualifier, "-")
+	if len(parts) > 1 && strings.HasPrefix(parts[1], "r") {
+		return parts[0] + "-" + strings.TrimPrefix(parts[1], "r")
+	}
+	return parts[0]
+}
+
+func androidEscape(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case ' ':
+			// the resource compiler collapses the spaces and trims the value
+			if i == 0 || i == len(s)-1 || s[i-1] == ' ' {
+				b.WriteString(`\u0020`)
+			} else {
+				b.WriteByte(' ')
+			}
+		case '@', '?':
+			// a leading @ or ? is a resource reference
+			if i == 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+/**
+* androidUnescape follows the resource compiler,
+* the white spaces out of double quotes are collapsed and the quotes are removed
+**/
+func androidUnescape(s string) (string, error) {
+	var (
+		b      strings.Builder
+		quoted bool
+		space  bool
+	)
+	s = strings.TrimSpace(s)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+			space = false
+		case c == '\\':
+			if i++; i == len(s) {
+				return "", fmt.Errorf("unterminated escape sequence")
+			}
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if i+4 >= len(s) {
+					return "", fmt.Errorf("invalid unicode escape sequence")
+				}
+				r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+				if err != nil {
+					return "", fmt.Errorf("invalid unicode escape sequence: %v", s[i+1:i+5])
+				}
+				b.WriteRune(rune(r))
+				i += 4
+			default:
+				b.WriteByte(s[i])
+			}
+			space = false
+		case !quoted && (c == ' ' || c == '\t' || c == '\n' || c == '\r'):
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+		default:
+			b.WriteByte(c)
+			space = false
+		}
+	}
+	return b.String(), nil
+}
+
+/**
+* DecodeAndroid reads a strings.xml resource file,
+* the dict is keyed by resource name and the xml comments above the resources are kept
+**/
+func DecodeAndroid(data []byte) (*I18nDict, error) {
+	d := &I18nDict{
+		Dict:     make(dict),
+		Plural:   make(pluralDict),
+		Comments: make(map[string]string),
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var (
+		comment string
+		name    string
+		// the quantity of the plural item being read
+		quantity string
+		text     strings.Builder
+		depth    int
+	)
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.Comment:
+			if depth == 1 {
+				comment = strings.TrimSpace(string(t))
+			}
+		case xml.StartElement:
+			depth++
+			attr := func(key string) string {
+				for _, a := range t.Attr {
+					if a.Name.Local == key {
+						return a.Value
+					}
+				}
+				return ""
+			}
+			switch {
+			case depth == 2 && (t.Name.Local == "string" || t.Name.Local == "plurals"):
+				if attr("translatable") == "false" {
+					if err := dec.Skip(); err != nil {
+						return nil, err
+					}
+					depth--
+					comment = ""
+					continue
+				}
+				name = attr("name")
+				if name == "" {
+					return nil, fmt.Errorf("Invalid android resource: missing name")
+				}
+				if comment != "" {
+					d.Comments[name] = comment
+					comment = ""
+				}
+				if t.Name.Local == "plurals" {
+					d.Plural[name] = make(plural)
+				}
+				text.Reset()
+			case depth == 3 && t.Name.Local == "item" && d.Plural[name] != nil:
+				quantity = attr("quantity")
+				if !language.IsPluralCategory(quantity) {
+					return nil, fmt.Errorf("Invalid plural quantity: %v of resource: %v", quantity, name)
+				}
+				text.Reset()
+			case depth > 2:
+				// markup like <b> is kept as text
+				text.WriteString("<" + t.Name.Local + ">")
+			}
+		case xml.EndElement:
+			switch {
+			case depth == 2 && t.Name.Local == "string":
+				val, err := androidUnescape(text.String())
+				if err != nil {
+					return nil, fmt.Errorf("Invalid android string: %v, error: %v", name, err)
+				}
+				d.Dict[name] = val
+			case depth == 3 && t.Name.Local == "item" && quantity != "":
+				val, err := androidUnescape(text.String())
+				if err != nil {
+					return nil, fmt.Errorf("Invalid android plural: %v, error: %v", name, err)
+				}
+				d.Plural[name][language.PluralCategory(quantity)] = val
+				quantity = ""
+			case depth > 2:
+				text.WriteString("</" + t.Name.Local + ">")
+			}
+			depth--
+		case xml.CharData:
+			if depth >= 2 {
+				text.Write(t)
+			}
+		}
+	}
+	return d, nil
+}
+
+// EncodeAndroid writes the dict as a strings.xml resource file, names maps the keys to resource names
+func EncodeAndroid(d *I18nDict, names map[string]string) ([]byte, error) {
+	keys := make([]string, 0, len(d.Dict)+len(d.Plural))
+	for key := range d.Dict {
+		if _, ok := d.Plural[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	for key := range d.Plural {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString("<resources>\n")
+	escape := func(s string) string {
+		return xmlText.Replace(androidEscape(s))
+	}
+	for _, key := range keys {
+		name, ok := names[key]
+		if !ok {
+			return nil, fmt.Errorf("Missing android resource name of key: %v", key)
+		}
+		if comment := d.Comments[key]; comment != "" {
+			// "--" is not allowed in xml comments
+			fmt.Fprintf(&buf, "    <!-- %v -->\n", strings.ReplaceAll(comment, "--", "- -"))
+		}
+		if forms, ok := d.Plural[key]; ok {
+			fmt.Fprintf(&buf, "    <plurals name=\"%v\">\n", name)
+			for _, category := range pluralOrder {
+				if form, ok := forms[category]; ok {
+					fmt.Fprintf(&buf, "        <item quantity=\"%v\">%v</item>\n", category, escape(form))
+				}
+			}
+			buf.WriteString("    </plurals>\n")
+		} else {
+			fmt.Fprintf(&buf, "    <string name=\"%v\">%v</string>\n", name, escape(d.Dict[key]))
+		}
+	}
+	buf.WriteString("</resources>\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,113 @@
+package i18n
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+func TestAndroidNames(t *testing.T) {
+	got := androidNames([]string{"menu.open", "menu_open", "2fa", "Hello world", "ключ"})
+	want := map[string]string{
+		"2fa":         "_2fa",
+		"Hello world": "Hello_world",
+		"menu.open":   "menu_open",
+		"menu_open":   "menu_open_2",
+		"ключ":        "____",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("androidNames() = %v, want %v", got, want)
+	}
+}
+
+func TestAndroidQualifier(t *testing.T) {
+	tests := []struct {
+		lang      language.I18nLang
+		qualifier string
+	}{
+		{language.English, "en"},
+		{language.BrazilianPortuguese, "pt-rBR"},
+		{language.TraditionalChinese, "b+zh+Hant"},
+		{language.LatinAmericanSpanish, "b+es+419"},
+	}
+	for _, tt := range tests {
+		if got := androidQualifier(tt.lang); got != tt.qualifier {
+			t.Errorf("androidQualifier(%v) = %v, want %v", tt.lang.Shortcut(), got, tt.qualifier)
+		}
+		if got := language.GetLang(androidLang(tt.qualifier)); got != tt.lang {
+			t.Errorf("androidLang(%v) = %v, want %v", tt.qualifier, got.Shortcut(), tt.lang.Shortcut())
+		}
+	}
+}
+
+func TestAndroidRoundTrip(t *testing.T) {
+	d := &I18nDict{
+		Dict: dict{
+			"hello":      "Hello %s",
+			"menu.open":  "Open <file> & \"save\"",
+			"apostrophe": "it's\nnew\tline \\",
+			"at":         "@string/not_a_reference ?",
+			"spaces":     " two  spaces ",
+		},
+		Plural: pluralDict{
+			"files": {language.PluralOne: "%d file", language.PluralFew: "%d files", language.PluralOther: "%d files"},
+		},
+		Comments: map[string]string{"hello": "greeting -- informal"},
+	}
+	names := androidNames([]string{"hello", "menu.open", "apostrophe", "at", "spaces", "files"})
+	data, err := EncodeAndroid(d, names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeAndroid(data)
+	if err != nil {
+		t.Fatalf("DecodeAndroid() error: %v\n%s", err, data)
+	}
+	got := androidKeys([]string{"hello", "menu.open", "apostrophe", "at", "spaces", "files"}, decoded)
+	assertDict(t, got, &I18nDict{
+		Dict:     d.Dict,
+		Plural:   d.Plural,
+		Comments: map[string]string{"hello": "greeting - - informal"},
+	})
+	if _, err := EncodeAndroid(d, map[string]string{}); err == nil {
+		t.Errorf("EncodeAndroid() without names succeeded")
+	}
+}
+
+func TestDecodeAndroid(t *testing.T) {
+	data := `<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <!-- shown on start -->
+    <string name="hello">  Hello
+        <b>world</b>  </string>
+    <string name="quoted">"  two  spaces  "</string>
+    <string name="unicode">café</string>
+    <string name="app_id" translatable="false">com.example</string>
+    <plurals name="files">
+        <item quantity="one">%d file</item>
+        <item quantity="other">%d files</item>
+    </plurals>
+</resources>
+`
+	got, err := DecodeAndroid([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDict(t, got, &I18nDict{
+		Dict:     dict{"hello": "Hello <b>world</b>", "quoted": "  two  spaces  ", "unicode": "café"},
+		Plural:   pluralDict{"files": {language.PluralOne: "%d file", language.PluralOther: "%d files"}},
+		Comments: map[string]string{"hello": "shown on start"},
+	})
+	for _, data := range []string{
+		`<resources><string>no name</string></resources>`,
+		`<resources><plurals name="a"><item quantity="several">x</item></plurals></resources>`,
+		`<resources><string name="a">trailing \</string></resources>`,
+		`<resources><string name="a">\u00</string></resources>`,
+		`<resources><string name="a">`,
+	} {
+		if _, err := DecodeAndroid([]byte(data)); err == nil {
+			t.Errorf("DecodeAndroid(%v) succeeded", data)
+		}
+	}
+}
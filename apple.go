@@ -0,0 +1,398 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+// appleTable is the table name of the default namespace
+const appleTable = "Localizable"
+
+// appleText decodes the UTF-16 files written by Xcode, the other files are UTF-8
+func appleText(data []byte) (string, error) {
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		order = binary.BigEndian
+	default:
+		data = bytes.TrimPrefix(data, []byte{0xef, 0xbb, 0xbf})
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("Invalid strings file: not utf-8 or utf-16")
+		}
+		return string(data), nil
+	}
+	data = data[2:]
+	if len(data)%2 != 0 {
+		return "", fmt.Errorf("Invalid strings file: odd utf-16 length")
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[i*2:])
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+var (
+	goStringVerb    = regexp.MustCompile(`%%|%(?:\[(\d+)\])?([-+# 0]*\d*(?:\.\d+)?)[sv]`)
+	appleObjectVerb = regexp.MustCompile(`%%|%(?:(\d+)\$)?([-+# 0]*\d*(?:\.\d+)?)@`)
+)
+
+// appleVerbs rewrites the string verbs %s and %v to the object verb %@ of apple, %[1]s becomes %1$@
+func appleVerbs(s string) string {
+	return goStringVerb.ReplaceAllStringFunc(s, func(verb string) string {
+		m := goStringVerb.FindStringSubmatch(verb)
+		if verb == "%%" {
+			return verb
+		}
+		if m[1] != "" {
+			return "%" + m[1] + "$" + m[2] + "@"
+		}
+		return "%" + m[2] + "@"
+	})
+}
+
+// goVerbs rewrites the object verb %@ of apple to %s, %1$@ becomes %[1]s
+func goVerbs(s string) string {
+	return appleObjectVerb.ReplaceAllStringFunc(s, func(verb string) string {
+		m := appleObjectVerb.FindStringSubmatch(verb)
+		if verb == "%%" {
+			return verb
+		}
+		if m[1] != "" {
+			return "%[" + m[1] + "]" + m[2] + "s"
+		}
+		return "%" + m[2] + "s"
+	})
+}
+
+/**
+* DecodeStrings reads the "key" = "value"; entries of an apple .strings file,
+* the block or line comment above an entry is kept, %@ is read as %s
+**/
+func DecodeStrings(data []byte) (*I18nDict, error) {
+	text, err := appleText(data)
+	if err != nil {
+		return nil, err
+	}
+	d := &I18nDict{
+		Dict:     make(dict),
+		Plural:   make(pluralDict),
+		Comments: make(map[string]string),
+	}
+	var (
+		pos     int
+		comment string
+	)
+	line := func() int {
+		return strings.Count(text[:pos], "\n") + 1
+	}
+	// skip moves to the next token, collecting the comments
+	skip := func() error {
+		for pos < len(text) {
+			switch {
+			case strings.ContainsRune(" \t\r\n", rune(text[pos])):
+				pos++
+			case strings.HasPrefix(text[pos:], "/*"):
+				end := strings.Index(text[pos+2:], "*/")
+				if end < 0 {
+					return fmt.Errorf("Invalid strings file, line %v: unterminated comment", line())
+				}
+				comment = strings.TrimSpace(text[pos+2 : pos+2+end])
+				pos += end + 4
+			case strings.HasPrefix(text[pos:], "//"):
+				end := strings.IndexByte(text[pos:], '\n')
+				if end < 0 {
+					end = len(text) - pos
+				}
+				comment = strings.TrimSpace(text[pos+2 : pos+end])
+				pos += end
+			default:
+				return nil
+			}
+		}
+		return nil
+	}
+	str := func() (string, error) {
+		if pos >= len(text) {
+			return "", fmt.Errorf("Invalid strings file, line %v: unexpected end", line())
+		}
+		if text[pos] != '"' {
+			// unquoted keys are allowed for identifiers
+			start := pos
+			for pos < len(text) && !strings.ContainsRune(" \t\r\n=;", rune(text[pos])) {
+				pos++
+			}
+			if start == pos {
+				return "", fmt.Errorf("Invalid strings file, line %v: expect a string", line())
+			}
+			return text[start:pos], nil
+		}
+		var b strings.Builder
+		for pos++; pos < len(text); pos++ {
+			c := text[pos]
+			switch c {
+			case '"':
+				pos++
+				return b.String(), nil
+			case '\\':
+				if pos++; pos == len(text) {
+					return "", fmt.Errorf("Invalid strings file, line %v: unterminated escape sequence", line())
+				}
+				switch text[pos] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				case 'U', 'u':
+					if pos+4 >= len(text) {
+						return "", fmt.Errorf("Invalid strings file, line %v: invalid unicode escape sequence", line())
+					}
+					r, err := strconv.ParseUint(text[pos+1:pos+5], 16, 32)
+					if err != nil {
+						return "", fmt.Errorf("Invalid strings file, line %v: invalid unicode escape sequence", line())
+					}
+					b.WriteRune(rune(r))
+					pos += 4
+				default:
+					b.WriteByte(text[pos])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("Invalid strings file, line %v: unterminated string", line())
+	}
+	expect := func(c byte) error {
+		if err := skip(); err != nil {
+			return err
+		}
+		if pos >= len(text) || text[pos] != c {
+			return fmt.Errorf("Invalid strings file, line %v: expect %q", line(), c)
+		}
+		pos++
+		return nil
+	}
+	for {
+		comment = ""
+		if err := skip(); err != nil {
+			return nil, err
+		}
+		if pos >= len(text) {
+			break
+		}
+		keyComment := comment
+		key, err := str()
+		if err != nil {
+			return nil, err
+		}
+		if err := expect('='); err != nil {
+			return nil, err
+		}
+		if err := skip(); err != nil {
+			return nil, err
+		}
+		val, err := str()
+		if err != nil {
+			return nil, err
+		}
+		if err := expect(';'); err != nil {
+			return nil, err
+		}
+		d.Dict[key] = goVerbs(val)
+		if keyComment != "" {
+			d.Comments[key] = keyComment
+		}
+	}
+	return d, nil
+}
+
+func appleQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// EncodeStrings writes the plain keys of the dict as an UTF-8 .strings file, %s and %v are written as %@
+func EncodeStrings(d *I18nDict) []byte {
+	keys := make([]string, 0, len(d.Dict))
+	for key := range d.Dict {
+		if _, ok := d.Plural[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if comment := d.Comments[key]; comment != "" {
+			fmt.Fprintf(&buf, "/* %v */\n", strings.ReplaceAll(comment, "*/", "* /"))
+		}
+		fmt.Fprintf(&buf, "%v = %v;\n", appleQuote(key), appleQuote(appleVerbs(d.Dict[key])))
+	}
+	return buf.Bytes()
+}
+
+// plistValue is a string or a dict of a property list, the other types are read as strings
+type plistValue struct {
+	str  string
+	keys []string
+	dict map[string]*plistValue
+}
+
+func decodePlist(dec *xml.Decoder, start xml.StartElement) (*plistValue, error) {
+	if start.Name.Local != "dict" {
+		var s string
+		if err := dec.DecodeElement(&s, &start); err != nil {
+			return nil, err
+		}
+		return &plistValue{str: s}, nil
+	}
+	v := &plistValue{dict: make(map[string]*plistValue)}
+	var key string
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "key" {
+				if err := dec.DecodeElement(&key, &t); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			val, err := decodePlist(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			v.keys = append(v.keys, key)
+			v.dict[key] = val
+		case xml.EndElement:
+			return v, nil
+		}
+	}
+}
+
+var stringsdictVariable = regexp.MustCompile(`%#@([^@]+)@`)
+
+/**
+* DecodeStringsdict reads the plural rules of an apple .stringsdict file,
+* the localized format may hold text around a single variable, it is copied into each form,
+* %@ is read as %s
+**/
+func DecodeStringsdict(data []byte) (*I18nDict, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var root *plistValue
+	for root == nil {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("Invalid stringsdict file: missing dict")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := token.(xml.StartElement); ok && t.Name.Local == "dict" {
+			if root, err = decodePlist(dec, t); err != nil {
+				return nil, err
+			}
+		}
+	}
+	d := &I18nDict{
+		Dict:   make(dict),
+		Plural: make(pluralDict),
+	}
+	for _, key := range root.keys {
+		entry := root.dict[key]
+		if entry.dict == nil {
+			continue
+		}
+		format := entry.dict["NSStringLocalizedFormatKey"]
+		if format == nil {
+			return nil, fmt.Errorf("Invalid stringsdict entry: %v, missing NSStringLocalizedFormatKey", key)
+		}
+		vars := stringsdictVariable.FindAllStringSubmatchIndex(format.str, -1)
+		if len(vars) != 1 {
+			return nil, fmt.Errorf("Invalid stringsdict entry: %v, expect a single variable", key)
+		}
+		rule := entry.dict[format.str[vars[0][2]:vars[0][3]]]
+		if rule == nil || rule.dict == nil {
+			return nil, fmt.Errorf("Invalid stringsdict entry: %v, missing variable", key)
+		}
+		forms := make(plural)
+		for _, category := range rule.keys {
+			if !language.IsPluralCategory(category) {
+				continue
+			}
+			forms[language.PluralCategory(category)] = goVerbs(format.str[:vars[0][0]] + rule.dict[category].str + format.str[vars[0][1]:])
+		}
+		d.Plural[key] = forms
+	}
+	return d, nil
+}
+
+var formatVerb = regexp.MustCompile(`%[-+# 0]*\d*(?:\.\d+)?(?:l|ll|h|hh|q|z|t|j)?([dDiuUxXoOfeEgGcCsSp@])`)
+
+// stringsdictValueType is the first number verb of the forms, the plural variable is the count, d by default
+func stringsdictValueType(forms plural) string {
+	for _, category := range pluralOrder {
+		for _, m := range formatVerb.FindAllStringSubmatch(forms[category], -1) {
+			if !strings.Contains("@sScCp", m[1]) {
+				return m[1]
+			}
+		}
+	}
+	return "d"
+}
+
+// EncodeStringsdict writes the plural keys of the dict as an apple .stringsdict file, %s and %v are written as %@
+func EncodeStringsdict(d *I18nDict) []byte {
+	keys := make([]string, 0, len(d.Plural))
+	for key := range d.Plural {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	escape := func(s string) string {
+		return xmlText.Replace(s)
+	}
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	buf.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	for _, key := range keys {
+		forms := make(plural, len(d.Plural[key]))
+		for category, form := range d.Plural[key] {
+			forms[category] = appleVerbs(form)
+		}
+		valueType := stringsdictValueType(forms)
+		fmt.Fprintf(&buf, "    <key>%v</key>\n    <dict>\n", escape(key))
+		buf.WriteString("        <key>NSStringLocalizedFormatKey</key>\n        <string>%#@value@</string>\n")
+		buf.WriteString("        <key>value</key>\n        <dict>\n")
+		buf.WriteString("            <key>NSStringFormatSpecTypeKey</key>\n            <string>NSStringPluralRuleType</string>\n")
+		fmt.Fprintf(&buf, "            <key>NSStringFormatValueTypeKey</key>\n            <string>%v</string>\n", valueType)
+		for _, category := range pluralOrder {
+			if form, ok := forms[category]; ok {
+				fmt.Fprintf(&buf, "            <key>%v</key>\n            <string>%v</string>\n", category, escape(form))
+			}
+		}
+		buf.WriteString("        </dict>\n    </dict>\n")
+	}
+	buf.WriteString("</dict>\n</plist>\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,154 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+func TestAppleVerbs(t *testing.T) {
+	tests := []struct {
+		goFormat    string
+		appleFormat string
+		// %v is read back as %s
+		back string
+	}{
+		{"Hello %s", "Hello %@", "Hello %s"},
+		{"%v and %d", "%@ and %d", "%s and %d"},
+		{"%[2]s before %[1]v", "%2$@ before %1$@", "%[2]s before %[1]s"},
+		{"%-10s|", "%-10@|", "%-10s|"},
+		{"100%% %s", "100%% %@", "100%% %s"},
+		{"%%s is literal", "%%s is literal", "%%s is literal"},
+		{"%.2f %x", "%.2f %x", "%.2f %x"},
+	}
+	for _, tt := range tests {
+		if got := appleVerbs(tt.goFormat); got != tt.appleFormat {
+			t.Errorf("appleVerbs(%q) = %q, want %q", tt.goFormat, got, tt.appleFormat)
+		}
+		if got := goVerbs(tt.appleFormat); got != tt.back {
+			t.Errorf("goVerbs(%q) = %q, want %q", tt.appleFormat, got, tt.back)
+		}
+	}
+}
+
+func TestStringsRoundTrip(t *testing.T) {
+	d := &I18nDict{
+		Dict: dict{
+			"hello":   "Hello %s",
+			"escaped": "say \"hi\"\\\n\ttabbed",
+			"percent": "100%% of %[1]s",
+		},
+		Comments: map[string]string{"hello": "greeting, the name is a */ comment end"},
+	}
+	data := EncodeStrings(d)
+	for _, want := range []string{`"hello" = "Hello %@";`, `"percent" = "100%% of %1$@";`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("EncodeStrings() does not contain %v:\n%s", want, data)
+		}
+	}
+	got, err := DecodeStrings(data)
+	if err != nil {
+		t.Fatalf("DecodeStrings() error: %v\n%s", err, data)
+	}
+	assertDict(t, got, &I18nDict{
+		Dict:     d.Dict,
+		Comments: map[string]string{"hello": "greeting, the name is a * / comment end"},
+	})
+}
+
+func TestDecodeStrings(t *testing.T) {
+	data := "\ufeff/* file comment */\n\n// line comment\n\"a\" = \"A %@\";\n\"b\"=\"B\\u00e9\";\n"
+	got, err := DecodeStrings([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDict(t, got, &I18nDict{
+		Dict:     dict{"a": "A %s", "b": "Bé"},
+		Comments: map[string]string{"a": "line comment"},
+	})
+	// utf-16 files written by xcode
+	utf16 := []byte{0xff, 0xfe}
+	for _, r := range `"k" = "v";` {
+		utf16 = append(utf16, byte(r), 0)
+	}
+	if got, err := DecodeStrings(utf16); err != nil || got.Dict["k"] != "v" {
+		t.Errorf("DecodeStrings(utf-16) = %v, %v, want map[k:v]", got, err)
+	}
+	for _, data := range []string{`"a" = "A"`, `"a" "A";`, `"a = "A";`, "\xff\xfe\x00"} {
+		if _, err := DecodeStrings([]byte(data)); err == nil {
+			t.Errorf("DecodeStrings(%q) succeeded", data)
+		}
+	}
+}
+
+func TestStringsdictRoundTrip(t *testing.T) {
+	d := &I18nDict{
+		Plural: pluralDict{
+			"files":  {language.PluralOne: "%s has %d file", language.PluralOther: "%s has %d files"},
+			"people": {language.PluralOne: "one <person>", language.PluralOther: "%d & more"},
+		},
+	}
+	data := EncodeStringsdict(d)
+	for _, want := range []string{
+		"<string>%@ has %d file</string>",
+		"<string>%d &amp; more</string>",
+		"<key>NSStringFormatValueTypeKey</key>\n            <string>d</string>",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("EncodeStringsdict() does not contain %v:\n%s", want, data)
+		}
+	}
+	if strings.Contains(string(data), "<string>@</string>") {
+		t.Errorf("EncodeStringsdict() uses the object verb as value type:\n%s", data)
+	}
+	got, err := DecodeStringsdict(data)
+	if err != nil {
+		t.Fatalf("DecodeStringsdict() error: %v\n%s", err, data)
+	}
+	assertDict(t, got, d)
+}
+
+func TestDecodeStringsdict(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+    <key>files</key>
+    <dict>
+        <key>NSStringLocalizedFormatKey</key>
+        <string>%@ has %#@count@</string>
+        <key>count</key>
+        <dict>
+            <key>NSStringFormatSpecTypeKey</key>
+            <string>NSStringPluralRuleType</string>
+            <key>NSStringFormatValueTypeKey</key>
+            <string>d</string>
+            <key>one</key>
+            <string>%d file</string>
+            <key>other</key>
+            <string>%d files</string>
+        </dict>
+    </dict>
+</dict>
+</plist>
+`
+	got, err := DecodeStringsdict([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDict(t, got, &I18nDict{
+		Plural: pluralDict{
+			"files": {language.PluralOne: "%s has %d file", language.PluralOther: "%s has %d files"},
+		},
+	})
+	for _, data := range []string{
+		`<plist></plist>`,
+		`<plist><dict><key>a</key><dict><key>x</key><string>y</string></dict></dict></plist>`,
+		`<plist><dict><key>a</key><dict><key>NSStringLocalizedFormatKey</key><string>%#@a@ %#@b@</string></dict></dict></plist>`,
+		`<plist><dict><key>a</key><dict><key>NSStringLocalizedFormatKey</key><string>%#@a@</string></dict></dict></plist>`,
+	} {
+		if _, err := DecodeStringsdict([]byte(data)); err == nil {
+			t.Errorf("DecodeStringsdict(%v) succeeded", data)
+		}
+	}
+}
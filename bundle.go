@@ -0,0 +1,225 @@
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yaou-li/go-i18n/language"
+)
+
+/**
+* Bundle owns its own options and loaded catalogs,
+* several bundles can live in the same process
+**/
+type Bundle struct {
+	opts       *I18nOpts
+	log        *logrus.Logger
+	loader     *loader
+	tenants    *tenantCache
+	runtimeDir string
+}
+
+func NewBundle(opts *I18nOpts, log *logrus.Logger) *Bundle {
+	bundle := &Bundle{
+		opts:    opts,
+		log:     log,
+		loader:  Newloader(opts, log),
+		tenants: newTenantCache(),
+	}
+	if dir, err := os.Getwd(); err != nil {
+		log.Error("Failed to get runtime folder.")
+	} else {
+		bundle.runtimeDir = dir
+	}
+	return bundle
+}
+
+// Load reads all the translation files under the language directory
+func (b *Bundle) Load() error {
+	return b.loader.load()
+}
+
+func (b *Bundle) GetLang() language.I18nLang {
+	return b.opts.GetTargetLang()
+}
+
+func (b *Bundle) GetShortcut() string {
+	return b.opts.GetTargetLang().Shortcut()
+}
+
+// UpdateLang switches the target language to the best enabled match of shortcut, if the match is good enough
+func (b *Bundle) UpdateLang(shortcut string) {
+	if b.opts.IsEnabled(shortcut) {
+		b.opts.SetTargetLang(shortcut)
+	} else if lang, conf := b.opts.Match(shortcut); conf >= language.High {
+		b.opts.SetTargetLang(lang.Shortcut())
+	}
+}
+
+// GetDicts returns a copy of the loaded dicts, changing it does not affect the lookups
+func (b *Bundle) GetDicts() map[language.I18nLang]dict {
+	return b.loader.getDicts()
+}
+
+func (b *Bundle) Trans(key string) string {
+	return b.trans(b.opts.GetTargetLang(), key)
+}
+
+func (b *Bundle) Transf(key string, a ...interface{}) string {
+	return fmt.Sprintf(b.trans(b.opts.GetTargetLang(), key), a...)
+}
+
+// TransPlural picks the plural form of count, a is passed to fmt.Sprintf
+func (b *Bundle) TransPlural(key string, count interface{}, a ...interface{}) string {
+	return fmt.Sprintf(b.transPlural(b.opts.GetTargetLang(), key, count), a...)
+}
+
+// TransMsg renders the translation as an ICU MessageFormat message
+func (b *Bundle) TransMsg(key string, args map[string]interface{}) string {
+	return b.transMsg(b.opts.GetTargetLang(), key, args)
+}
+
+/**
+* TransNamed replaces the {{.Name}} or {name} placeholders,
+* params is a map with string keys or a struct, fields can be renamed with an `i18n` tag
+**/
+func (b *Bundle) TransNamed(key string, params interface{}) string {
+	return b.transNamed(b.opts.GetTargetLang(), key, params)
+}
+
+// NewLocalizer returns a localizer bound to the language, the target language is used if it is not enabled
+func (b *Bundle) NewLocalizer(shortcut string) *Localizer {
+	lang := b.opts.GetTargetLang()
+	if b.opts.IsEnabled(shortcut) {
+		lang = language.GetLang(shortcut)
+	}
+	return &Localizer{
+		bundle: b,
+		lang:   lang,
+	}
+}
+
+/**
+* trans must be called directly by the exported api,
+* the caller is used to locate the namespace
+**/
+func (b *Bundle) trans(lang language.I18nLang, key string) string {
+	return b.translate(nil, lang, key, b.callerNamespace(key))
+}
+
+// transPlural follows the same calling convention as trans
+func (b *Bundle) transPlural(lang language.I18nLang, key string, count interface{}) string {
+	return b.translatePlural(nil, lang, key, count, b.callerNamespace(key))
+}
+
+// transMsg follows the same calling convention as trans
+func (b *Bundle) transMsg(lang language.I18nLang, key string, args map[string]interface{}) string {
+	return b.translateMsg(nil, lang, key, args, b.callerNamespace(key))
+}
+
+// transNamed follows the same calling convention as trans
+func (b *Bundle) transNamed(lang language.I18nLang, key string, params interface{}) string {
+	return b.translateNamed(nil, lang, key, params, b.callerNamespace(key))
+}
+
+// tenant holds the overrides searched before the catalog, nil without tenant
+func (b *Bundle) translate(tenant *catalog, lang language.I18nLang, key string, namespace string) string {
+	val, _ := b.lookup(tenant, lang, key, namespace)
+	return val
+}
+
+func (b *Bundle) translatePlural(tenant *catalog, lang language.I18nLang, key string, count interface{}, namespace string) string {
+	if val, _, ok := b.loader.findPlural(tenant, lang, key, count, namespace); ok {
+		return val
+	}
+	return key
+}
+
+func (b *Bundle) translateMsg(tenant *catalog, lang language.I18nLang, key string, args map[string]interface{}, namespace string) string {
+	val, served := b.lookup(tenant, lang, key, namespace)
+	msg, ok := b.loader.message(tenant, val)
+	if !ok {
+		b.log.Errorf("Invalid message of key: %v", key)
+		return val
+	}
+	res, err := msg.Format(served, args)
+	if err != nil {
+		b.log.Errorf("Failed to format message of key: %v, error: %v", key, err)
+	}
+	return res
+}
+
+func (b *Bundle) translateNamed(tenant *catalog, lang language.I18nLang, key string, params interface{}, namespace string) string {
+	val, _ := b.lookup(tenant, lang, key, namespace)
+	named, err := namedParams(params)
+	if err != nil {
+		b.log.Errorf("Failed to get named params of key: %v, error: %v", key, err)
+		return val
+	}
+	res, err := replaceNamed(val, named)
+	if err != nil {
+		b.log.Errorf("Failed to replace placeholders of key: %v, error: %v", key, err)
+	}
+	return res
+}
+
+// lookup returns the translation and the language serving it, the key is returned if it is missing
+func (b *Bundle) lookup(tenant *catalog, lang language.I18nLang, key string, namespace string) (string, language.I18nLang) {
+	if val, served, ok := b.loader.find(tenant, lang, key, namespace); ok {
+		return val, served
+	}
+	return key, lang
+}
+
+/**
+* callerNamespace resolves the namespace, without the language prefix, from the caller of the exported api,
+* it returns an empty namespace if namespace mode is disabled
+**/
+func (b *Bundle) callerNamespace(key string) string {
+	if !b.opts.enableNamespace {
+		return ""
+	}
+	_, fpath, _, ok := runtime.Caller(3)
+	if !ok {
+		b.log.Errorf("Failed to get caller of trans function, key: %v", key)
+		return ""
+	}
+	return GetNamespace(filepath.Dir(fpath), b.runtimeDir, b.opts.splitter)
+}
+
+// Localizer translates with a fixed language
+type Localizer struct {
+	bundle *Bundle
+	lang   language.I18nLang
+}
+
+func (l *Localizer) GetLang() language.I18nLang {
+	return l.lang
+}
+
+func (l *Localizer) GetShortcut() string {
+	return l.lang.Shortcut()
+}
+
+func (l *Localizer) Trans(key string) string {
+	return l.bundle.trans(l.lang, key)
+}
+
+func (l *Localizer) Transf(key string, a ...interface{}) string {
+	return fmt.Sprintf(l.bundle.trans(l.lang, key), a...)
+}
+
+func (l *Localizer) TransPlural(key string, count interface{}, a ...interface{}) string {
+	return fmt.Sprintf(l.bundle.transPlural(l.lang, key, count), a...)
+}
+
+func (l *Localizer) TransMsg(key string, args map[string]interface{}) string {
+	return l.bundle.transMsg(l.lang, key, args)
+}
+
+func (l *Localizer) TransNamed(key string, params interface{}) string {
+	return l.bundle.transNamed(l.lang, key, params)
+}
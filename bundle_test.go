@@ -0,0 +1,121 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestBundle(t *testing.T, dir string) *Bundle {
+	t.Helper()
+	opts := NewI18nOpts()
+	opts.SetEnableLangs("en,ko")
+	opts.SetTargetLang("en")
+	opts.SetLanguageDir(dir)
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	b := NewBundle(opts, log)
+	if err := b.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestConcurrentReloadAndTrans is meant to run with -race
+func TestConcurrentReloadAndTrans(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "en.json", `{"language":"en","dict":{"hello":"Hello","items":{"one":"# item","other":"# items"}}}`)
+	writeTestFile(t, dir, "ko.json", `{"language":"ko","dict":{"hello":"안녕하세요"}}`)
+	b := newTestBundle(t, dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := b.Watch(ctx, time.Millisecond)
+	go func() {
+		for range events {
+		}
+	}()
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if val := b.Trans("hello"); val == "" {
+					t.Error("empty translation")
+					return
+				}
+				b.TransPlural("items", 2)
+				b.TransCtx(WithLang(ctx, "ko"), "hello")
+				b.GetDicts()
+			}
+		}()
+	}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			b.UpdateLang([]string{"en", "ko"}[i%2])
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			writeTestFile(t, dir, "en.json", fmt.Sprintf(`{"language":"en","dict":{"hello":"Hello %d"}}`, i))
+			if err := b.Reload(); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(done)
+	wg.Wait()
+}
+
+func TestGetDictsReturnsCopy(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "en.json", `{"language":"en","dict":{"hello":"Hello"}}`)
+	b := newTestBundle(t, dir)
+	for _, d := range b.GetDicts() {
+		d["hello"] = "changed"
+	}
+	if val := b.Trans("hello"); val != "Hello" {
+		t.Errorf("Trans(hello) = %v, want Hello", val)
+	}
+}
+
+func TestRefreshKeepsBrokenFileReported(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "en.json", `{"language":"en","dict":{"hello":"Hello"}}`)
+	writeTestFile(t, dir, "ko.json", `{"language":"ko","dict":{"hello":"안녕"}}`)
+	b := newTestBundle(t, dir)
+	writeTestFile(t, dir, "en.json", `{"language":"en","dict":{"hello":"Hi"}}`)
+	writeTestFile(t, dir, "ko.json", `{"language":"ko",`)
+	for i := 0; i < 2; i++ {
+		if err := b.Reload(); err == nil {
+			t.Fatal("Reload of a broken file succeeded")
+		}
+		if val := b.Trans("hello"); val != "Hello" {
+			t.Fatalf("Trans(hello) = %v, want the previous catalog", val)
+		}
+	}
+	writeTestFile(t, dir, "ko.json", `{"language":"ko","dict":{"hello":"안녕"}}`)
+	if err := b.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if val := b.Trans("hello"); val != "Hi" {
+		t.Errorf("Trans(hello) = %v, want Hi", val)
+	}
+}
@@ -7,21 +7,53 @@ import (
 	"go/token"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 	"github.com/yaou-li/go-i18n"
 )
 
+// transFuncs maps the trans functions to the position of their key argument
+var transFuncs = map[string]int{
+	"Trans":         0,
+	"Transf":        0,
+	"TransCtx":      1,
+	"TransfCtx":     1,
+	"TransMsg":      0,
+	"TransNamed":    0,
+	"TransMsgCtx":   1,
+	"TransNamedCtx": 1,
+	// plural api
+	"TransPlural":    0,
+	"TransPluralCtx": 1,
+}
+
+var pluralFuncs = map[string]bool{
+	"TransPlural":    true,
+	"TransPluralCtx": true,
+}
+
 type I18nExtractor interface {
 	Extract(sourced string, clean bool) error
+	SetModule(moduleRoot string, modulePath string)
+	SetGenerate(gen bool)
+	SetRewrite(rewrite bool)
+	Compile() error
+	Export(name string, dir string) error
+	Import(name string, fpath string) error
 }
 
 type extractor struct {
-	opts   *i18n.I18nOpts
-	reader i18n.I18nReader
-	writer i18n.I18nWriter
-	log    *logrus.Logger
+	opts       *i18n.I18nOpts
+	reader     i18n.I18nReader
+	writer     i18n.I18nWriter
+	log        *logrus.Logger
+	dicts      map[string]*i18n.I18nDict
+	moduleRoot string
+	modulePath string
+	gen        bool
+	rewrite    bool
 }
 
 func NewExtractor(opts *i18n.I18nOpts) I18nExtractor {
@@ -36,7 +68,71 @@ func NewExtractor(opts *i18n.I18nOpts) I18nExtractor {
 		writer: i18n.NewWriter(opts, dicts),
 		reader: i18n.NewReader(opts, dicts),
 		log:    log,
+		dicts:  dicts,
+	}
+}
+
+// SetModule sets the module used to resolve the import paths of namespace handles
+func (ex *extractor) SetModule(moduleRoot string, modulePath string) {
+	ex.moduleRoot = moduleRoot
+	ex.modulePath = modulePath
+	ex.opts.SetModulePath(modulePath)
+}
+
+/**
+* SetGenerate writes an i18n_gen.go namespace handle in every package calling the package level trans functions,
+* the calls are reported but left as they are unless SetRewrite is enabled
+**/
+func (ex *extractor) SetGenerate(gen bool) {
+	ex.gen = gen
+}
+
+// SetRewrite rewrites the package level trans calls to go through the generated handle, it implies SetGenerate
+func (ex *extractor) SetRewrite(rewrite bool) {
+	ex.rewrite = rewrite
+	if rewrite {
+		ex.gen = true
+	}
+}
+
+// Compile compiles every catalog of the file type into a mo file next to it
+func (ex *extractor) Compile() error {
+	if err := ex.reader.ReadAllFile(); err != nil {
+		ex.log.Error(err)
+		return err
+	}
+	for namespace, dict := range ex.dicts {
+		if err := ex.writer.WriteFormat("mo", namespace, dict); err != nil {
+			ex.log.Errorf("Failed to compile %v, error: %v", namespace, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// Export writes the catalogs with a registered exchange format into dir
+func (ex *extractor) Export(name string, dir string) error {
+	if err := ex.reader.ReadAllFile(); err != nil {
+		ex.log.Error(err)
+		return err
 	}
+	if err := ex.writer.Export(name, dir); err != nil {
+		ex.log.Errorf("Failed to export %v, error: %v", name, err)
+		return err
+	}
+	return nil
+}
+
+// Import merges the translations read with a registered exchange format into the catalogs
+func (ex *extractor) Import(name string, fpath string) error {
+	if err := ex.reader.ReadAllFile(); err != nil {
+		ex.log.Error(err)
+	}
+	if err := ex.writer.Import(name, fpath); err != nil {
+		ex.log.Errorf("Failed to import %v, error: %v", name, err)
+		return err
+	}
+	return nil
 }
 
 /**
@@ -59,40 +155,83 @@ func (ex *extractor) Extract(sourced string, clean bool) error {
 		return err
 	}
 	fset := token.NewFileSet()
+	nodes := make(map[string]*ast.File)
+	// namespace handles are package level, collect them from every file of the package first
+	handles := make(map[string]map[string]string)
 	for _, fname := range files {
 		node, err := parser.ParseFile(fset, fname, nil, parser.ParseComments)
 		if err != nil {
 			ex.log.Errorf("Error when parsing source file: %v, error: %v", fname, err)
+			if node == nil {
+				continue
+			}
+		}
+		nodes[fname] = node
+		dir := path.Dir(fname)
+		if _, ok := handles[dir]; !ok {
+			handles[dir] = make(map[string]string)
+		}
+		namespaceHandles(node, handles[dir])
+	}
+	/**
+	* with -gen, a handle is generated in the packages calling the package level trans functions,
+	* with -rewrite, these calls are rewritten to use the handle and their keys are recorded under
+	* the namespace of the import path
+	**/
+	unhandled := make(map[string]string)
+	rewrites := make(map[string][]int)
+	for _, fname := range files {
+		node, ok := nodes[fname]
+		if !ok {
+			continue
 		}
+		dir := path.Dir(fname)
+		pkgName := i18nImport(node)
 		ast.Inspect(node, func(n ast.Node) bool {
-			switch x := n.(type) {
-			case *ast.File:
-				fmt.Sprint(x.Name)
+			switch n.(type) {
 			case *ast.CallExpr:
 				if callexp, ok := n.(*ast.CallExpr); !ok {
 					return false
 				} else {
 					if fun, ok := callexp.Fun.(*ast.SelectorExpr); ok {
-						// if pack, ok := fun.X.(*ast.Ident); (fun.Sel.Name == "Trans" || fun.Sel.Name == "Transf") && ok {
-						if _, ok := fun.X.(*ast.Ident); (fun.Sel.Name == "Trans" || fun.Sel.Name == "Transf") && ok {
-							fpath := i18n.GetNamespace(path.Dir(fname), sourced, ex.opts.GetSplitter())
-							if len(callexp.Args) == 0 {
+						idx, isTrans := transFuncs[fun.Sel.Name]
+						if ident, ok := fun.X.(*ast.Ident); isTrans && ok {
+							fpath := i18n.GetNamespace(dir, sourced, ex.opts.GetSplitter())
+							importPath, isHandle := handles[dir][ident.Name]
+							if isHandle {
+								fpath = ex.opts.ImportNamespace(importPath)
+							} else if ex.gen && ident.Name == pkgName && !strings.HasSuffix(node.Name.Name, "_test") {
+								if importPath, err := ex.importPath(dir); err == nil {
+									unhandled[dir] = node.Name.Name
+									if ex.rewrite {
+										rewrites[fname] = append(rewrites[fname], fset.Position(ident.Pos()).Offset)
+										fpath = ex.opts.ImportNamespace(importPath)
+									} else {
+										ex.log.Infof("%v: %v.%v can use the generated handle %v", ex.reference(fset.Position(callexp.Pos()), sourced), ident.Name, fun.Sel.Name, genHandle)
+									}
+								} else {
+									ex.log.Error(err)
+								}
+							}
+							if len(callexp.Args) <= idx {
 								ex.log.Error("Missing translation data")
 								return false
 							}
-							key, ok := callexp.Args[0].(*ast.BasicLit)
+							key, ok := callexp.Args[idx].(*ast.BasicLit)
 							if !ok {
 								ex.log.Error("Unable to get key value")
 								return false
 							}
+							namespace := "index"
 							if ex.opts.IsNamespaced() {
-								ex.writer.Append(fpath, strings.Trim(key.Value, "\""))
+								namespace = fpath
+							}
+							if pluralFuncs[fun.Sel.Name] {
+								ex.writer.AppendPlural(namespace, strings.Trim(key.Value, "\""))
 							} else {
-								ex.writer.Append("index", strings.Trim(key.Value, "\""))
+								ex.writer.Append(namespace, strings.Trim(key.Value, "\""))
 							}
-							// fmt.Println("------Package name------")
-							// fmt.Println(pack.Name, fun.Sel.Name, fname, fpath, strings.Trim(key.Value, "\""))
-							// fmt.Println("------Package name------")
+							ex.writer.AppendReference(namespace, strings.Trim(key.Value, "\""), ex.reference(fset.Position(callexp.Pos()), sourced))
 						}
 					}
 				}
@@ -101,8 +240,32 @@ func (ex *extractor) Extract(sourced string, clean bool) error {
 		})
 	}
 
+	for dir, pkg := range unhandled {
+		// the handle of a previous run is reused
+		if _, ok := handles[dir][genHandle]; ok {
+			continue
+		}
+		if err := ex.generate(dir, pkg); err != nil {
+			ex.log.Errorf("Failed to generate namespace handle in %v, error: %v", dir, err)
+		}
+	}
+	for fname, offsets := range rewrites {
+		if err := rewrite(fname, nodes[fname], fset, offsets); err != nil {
+			ex.log.Errorf("Failed to rewrite trans calls in %v, error: %v", fname, err)
+		}
+	}
+
 	if err := ex.writer.Flush(); err != nil {
 		ex.log.Errorf("Failed to flush to i18n files, error: %v", err)
 	}
 	return nil
 }
+
+// reference returns the position of a call as file:line, relative to the src directory
+func (ex *extractor) reference(pos token.Position, sourced string) string {
+	fname := pos.Filename
+	if rel, err := filepath.Rel(sourced, fname); err == nil {
+		fname = rel
+	}
+	return fmt.Sprintf("%v:%v", filepath.ToSlash(fname), pos.Line)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yaou-li/go-i18n"
+)
+
+func newTestExtractor(t *testing.T, dir string) I18nExtractor {
+	t.Helper()
+	opts := i18n.NewI18nOpts()
+	opts.SetEnableLangs("en,ko")
+	opts.SetTargetLang("en")
+	opts.SetLanguageDir(dir)
+	return NewExtractor(opts)
+}
+
+func TestExtract(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "src", "main.go"), []byte(`package main
+
+import "github.com/yaou-li/go-i18n"
+
+func main() {
+	i18n.Trans("hello")
+	i18n.Transf("welcome %v", "me")
+	i18n.TransPlural("%d files", 2)
+}
+`))
+	dir := filepath.Join(root, "i18n")
+	writeFile(t, filepath.Join(dir, "ko", "index.json"), []byte(`{"language":"ko","dict":{"hello":"안녕하세요"}}`))
+	if err := newTestExtractor(t, dir).Extract(filepath.Join(root, "src"), false); err != nil {
+		t.Fatal(err)
+	}
+	var ko struct {
+		Dict map[string]interface{} `json:"dict"`
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "ko", "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &ko); err != nil {
+		t.Fatal(err)
+	}
+	// the existing translations are kept, the plural key gets the categories of the language
+	want := map[string]interface{}{
+		"hello":      "안녕하세요",
+		"welcome %v": "",
+		"%d files":   map[string]interface{}{"other": ""},
+	}
+	if !reflect.DeepEqual(ko.Dict, want) {
+		t.Errorf("ko dict = %v, want %v", ko.Dict, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "en", "index.json")); err != nil {
+		t.Errorf("en catalog was not written: %v", err)
+	}
+}
+
+func TestExtractorExchange(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "i18n")
+	writeFile(t, filepath.Join(dir, "en", "index.json"), []byte(`{"language":"en","dict":{"hello":"Hello %s"}}`))
+	writeFile(t, filepath.Join(dir, "ko", "index.json"), []byte(`{"language":"ko","dict":{"hello":"안녕하세요 %s"}}`))
+	out := filepath.Join(root, "ios")
+	if err := newTestExtractor(t, dir).Export("ios", out); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(out, "ko.lproj", "Localizable.strings"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\"hello\" = \"안녕하세요 %@\";\n"; string(data) != want {
+		t.Errorf("Localizable.strings = %q, want %q", data, want)
+	}
+	writeFile(t, filepath.Join(out, "ko.lproj", "Localizable.strings"), []byte(`"hello" = "반가워요 %@";`))
+	if err := newTestExtractor(t, dir).Import("ios", out); err != nil {
+		t.Fatal(err)
+	}
+	data, err = ioutil.ReadFile(filepath.Join(dir, "ko", "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ko struct {
+		Dict map[string]string `json:"dict"`
+	}
+	if err := json.Unmarshal(data, &ko); err != nil {
+		t.Fatal(err)
+	}
+	if got := ko.Dict["hello"]; got != "반가워요 %s" {
+		t.Errorf("imported hello = %v, want 반가워요 %%s", got)
+	}
+	for _, name := range []string{"csv", "xliff"} {
+		if err := newTestExtractor(t, dir).Export(name, out); err == nil {
+			t.Errorf("Export(%v) succeeded", name)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"flag"
+	"log"
+	"strings"
 
 	"github.com/yaou-li/go-i18n"
 )
@@ -10,16 +12,61 @@ var (
 	src       = flag.String("src", ".", "set the golang src directory")
 	clean     = flag.Bool("clean", false, "clear all data")
 	namespace = flag.Bool("namespace", false, "use namespace mode")
+	module    = flag.String("module", "", "set the module path of the src directory, read from go.mod by default")
+	gen       = flag.Bool("gen", false, "generate an i18n_gen.go namespace handle in each package calling the package level i18n functions and report the calls")
+	rewriteF  = flag.Bool("rewrite", false, "rewrite the package level i18n calls to use the namespace handle, implies -gen")
+	fileType  = flag.String("type", "json", "set the translation file type, one of: "+strings.Join(i18n.ExtractFormats(), ", "))
+	nested    = flag.Bool("nested", false, "write the keys as nested objects split by the splitter, json only")
+	compile   = flag.Bool("compile", false, "compile the catalogs of the file type into mo files instead of extracting")
+	exportTo  = flag.String("export-to", "", "export the catalogs with an exchange format instead of extracting, one of: "+strings.Join(i18n.ExchangeFormats(), ", "))
+	importFr  = flag.String("import-from", "", "import the translations with an exchange format instead of extracting, one of: "+strings.Join(i18n.ExchangeFormats(), ", "))
+	exchange  = flag.String("exchange-path", ".", "set the directory of -export-to, and the file or directory of -import-from")
 )
 
 func main() {
 	flag.Parse()
 
+	if _, err := i18n.LookupFormat(*fileType); err != nil {
+		log.Fatalf("Failed to set file type, error: %v, registered types: %v", err, strings.Join(i18n.ExtractFormats(), ", "))
+	}
+	// the compiled formats are only written by -compile
+	if i18n.IsCompiled(*fileType) {
+		log.Fatalf("Failed to set file type, %v is compiled with -compile from the catalogs of another type: %v", *fileType, strings.Join(i18n.ExtractFormats(), ", "))
+	}
 	opts := i18n.NewI18nOpts()
 	opts.SetLanguageDir("./i18n")
 	opts.SetTargetLang("en")
+	opts.SetFileType(*fileType)
 	opts.SetEnableNamespace(*namespace)
+	opts.SetNestedKeys(*nested)
 
 	ex := NewExtractor(opts)
+	if *compile {
+		if err := ex.Compile(); err != nil {
+			log.Fatalf("Failed to compile catalogs, error: %v", err)
+		}
+		return
+	}
+	if *exportTo != "" {
+		if err := ex.Export(*exportTo, *exchange); err != nil {
+			log.Fatalf("Failed to export catalogs, error: %v", err)
+		}
+		return
+	}
+	if *importFr != "" {
+		if err := ex.Import(*importFr, *exchange); err != nil {
+			log.Fatalf("Failed to import translations, error: %v", err)
+		}
+		return
+	}
+	if *module != "" {
+		ex.SetModule(*src, *module)
+	} else if root, modulePath, err := findModule(*src); err == nil {
+		ex.SetModule(root, modulePath)
+	} else if *gen || *rewriteF {
+		log.Fatalf("Failed to find the module of %v, error: %v", *src, err)
+	}
+	ex.SetGenerate(*gen)
+	ex.SetRewrite(*rewriteF)
 	ex.Extract(*src, *clean)
 }
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	genFile   = "i18n_gen.go"
+	genHandle = "i18nNS"
+	i18nPath  = "github.com/yaou-li/go-i18n"
+)
+
+/**
+* findModule walks up from dir to the go.mod file,
+* it returns the module root directory and the module path
+**/
+func findModule(dir string) (string, string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	for {
+		modulePath, err := readModulePath(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			return dir, modulePath, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", fmt.Errorf("Unable to find go.mod")
+		}
+		dir = parent
+	}
+}
+
+// readModulePath reads the module directive of a go.mod file
+func readModulePath(fpath string) (string, error) {
+	fp, err := os.Open(fpath)
+	if err != nil {
+		return "", err
+	}
+	defer fp.Close()
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module") {
+			modulePath := strings.TrimSpace(strings.TrimPrefix(line, "module"))
+			if unquoted, err := strconv.Unquote(modulePath); err == nil {
+				modulePath = unquoted
+			}
+			return modulePath, nil
+		}
+	}
+	return "", fmt.Errorf("Missing module directive in %v", fpath)
+}
+
+// namespaceHandles collects the variables assigned from Namespace("import/path") calls
+func namespaceHandles(node *ast.File, handles map[string]string) {
+	record := func(names []*ast.Ident, values []ast.Expr) {
+		for i, value := range values {
+			if i >= len(names) {
+				return
+			}
+			if importPath, ok := namespaceCall(value); ok {
+				handles[names[i].Name] = importPath
+			}
+		}
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.ValueSpec:
+			record(x.Names, x.Values)
+		case *ast.AssignStmt:
+			var names []*ast.Ident
+			for _, lhs := range x.Lhs {
+				ident, ok := lhs.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				names = append(names, ident)
+			}
+			record(names, x.Rhs)
+		}
+		return true
+	})
+}
+
+func namespaceCall(expr ast.Expr) (string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return "", false
+	}
+	fun, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || fun.Sel.Name != "Namespace" {
+		return "", false
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok {
+		return "", false
+	}
+	importPath, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	return importPath, true
+}
+
+// i18nImport returns the name the file imports the i18n package under, empty if it is not imported
+func i18nImport(node *ast.File) string {
+	for _, spec := range node.Imports {
+		if importPath, err := strconv.Unquote(spec.Path.Value); err != nil || importPath != i18nPath {
+			continue
+		}
+		if spec.Name != nil {
+			return spec.Name.Name
+		}
+		return "i18n"
+	}
+	return ""
+}
+
+/**
+* rewrite replaces the package qualifier of the trans calls at offsets by the generated handle,
+* the i18n import is removed once nothing else uses it
+**/
+func rewrite(fname string, node *ast.File, fset *token.FileSet, offsets []int) error {
+	src, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return err
+	}
+	name := i18nImport(node)
+	uses := 0
+	ast.Inspect(node, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == name {
+				uses++
+			}
+		}
+		return true
+	})
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	for _, offset := range offsets {
+		edits = append(edits, edit{offset, offset + len(name), genHandle})
+	}
+	if uses == len(offsets) {
+		for _, decl := range node.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.IMPORT {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				if importPath, _ := strconv.Unquote(spec.(*ast.ImportSpec).Path.Value); importPath != i18nPath {
+					continue
+				}
+				start, end := spec.Pos(), spec.End()
+				if !gen.Lparen.IsValid() {
+					start, end = gen.Pos(), gen.End()
+				}
+				edits = append(edits, edit{fset.Position(start).Offset, fset.Position(end).Offset, ""})
+			}
+		}
+	}
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	for _, e := range edits {
+		src = append(src[:e.start:e.start], append([]byte(e.text), src[e.end:]...)...)
+	}
+	formatted, err := format.Source(src)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fname, formatted, 0644)
+}
+
+// importPath returns the import path of a package directory
+func (ex *extractor) importPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(ex.moduleRoot, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("Package %v is outside of the module %v", dir, ex.moduleRoot)
+	}
+	if rel == "." {
+		return ex.modulePath, nil
+	}
+	return path.Join(ex.modulePath, filepath.ToSlash(rel)), nil
+}
+
+// generate writes the namespace handle used by the rewritten trans calls of the package in dir
+func (ex *extractor) generate(dir string, pkg string) error {
+	importPath, err := ex.importPath(dir)
+	if err != nil {
+		return err
+	}
+	content := fmt.Sprintf(`// Code generated by go-i18n extract. DO NOT EDIT.
+
+package %v
+
+import "%v"
+
+// %v translates in the namespace of this package
+var %v = i18n.Namespace(%q)
+`, pkg, i18nPath, genHandle, genHandle, importPath)
+	return ioutil.WriteFile(filepath.Join(dir, genFile), []byte(content), 0644)
+}
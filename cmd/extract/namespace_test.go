@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yaou-li/go-i18n"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// transOffsets returns the offsets of the package qualifiers of the trans calls of node
+func transOffsets(node *ast.File, fset *token.FileSet) []int {
+	name := i18nImport(node)
+	var offsets []int
+	ast.Inspect(node, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if _, isTrans := transFuncs[sel.Sel.Name]; isTrans {
+				if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == name {
+					offsets = append(offsets, fset.Position(ident.Pos()).Offset)
+				}
+			}
+		}
+		return true
+	})
+	return offsets
+}
+
+func TestRewrite(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/rewrite/*.input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range inputs {
+		src, err := ioutil.ReadFile(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fname := filepath.Join(t.TempDir(), "app.go")
+		if err := ioutil.WriteFile(fname, src, 0644); err != nil {
+			t.Fatal(err)
+		}
+		fset := token.NewFileSet()
+		node, err := parser.ParseFile(fset, fname, nil, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rewrite(fname, node, fset, transOffsets(node, fset)); err != nil {
+			t.Errorf("rewrite(%v) error: %v", input, err)
+			continue
+		}
+		got, err := ioutil.ReadFile(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		golden := strings.TrimSuffix(input, ".input") + ".golden"
+		if *update {
+			if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("rewrite(%v) =\n%s\nwant\n%s", input, got, want)
+		}
+	}
+}
+
+func TestGenerateWithoutRewrite(t *testing.T) {
+	for _, rewrite := range []bool{false, true} {
+		root := t.TempDir()
+		src, err := ioutil.ReadFile("testdata/rewrite/only.input")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fname := filepath.Join(root, "app", "app.go")
+		writeFile(t, filepath.Join(root, "go.mod"), []byte("module example.com/m\n"))
+		writeFile(t, fname, src)
+
+		opts := i18n.NewI18nOpts()
+		opts.SetEnableLangs("en")
+		opts.SetTargetLang("en")
+		opts.SetLanguageDir(filepath.Join(root, "i18n"))
+		ex := NewExtractor(opts)
+		ex.SetModule(root, "example.com/m")
+		ex.SetGenerate(true)
+		ex.SetRewrite(rewrite)
+		if err := ex.Extract(filepath.Join(root, "app"), false); err != nil {
+			t.Fatal(err)
+		}
+		gen, err := ioutil.ReadFile(filepath.Join(root, "app", genFile))
+		if err != nil {
+			t.Fatalf("rewrite %v: %v was not generated: %v", rewrite, genFile, err)
+		}
+		if !strings.Contains(string(gen), `i18n.Namespace("example.com/m/app")`) {
+			t.Errorf("rewrite %v: unexpected handle:\n%s", rewrite, gen)
+		}
+		got, err := ioutil.ReadFile(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed := string(got) != string(src); changed != rewrite {
+			t.Errorf("rewrite %v: source changed = %v", rewrite, changed)
+		}
+	}
+}
+
+func writeFile(t *testing.T, fname string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(fname, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
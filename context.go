@@ -0,0 +1,141 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+type langCtxKey struct{}
+
+// WithLang returns a copy of ctx carrying the language, unsupported languages are ignored
+func WithLang(ctx context.Context, shortcut string) context.Context {
+	if !language.IsSupported(shortcut) {
+		return ctx
+	}
+	return context.WithValue(ctx, langCtxKey{}, language.GetLang(shortcut))
+}
+
+// LangFromContext returns the language stored by WithLang
+func LangFromContext(ctx context.Context) (language.I18nLang, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	lang, ok := ctx.Value(langCtxKey{}).(language.I18nLang)
+	return lang, ok
+}
+
+// langFromContext falls back to the target language when ctx has no enabled language
+func (b *Bundle) langFromContext(ctx context.Context) language.I18nLang {
+	if lang, ok := LangFromContext(ctx); ok && b.opts.IsEnabled(lang.Shortcut()) {
+		return lang
+	}
+	return b.opts.GetTargetLang()
+}
+
+type tenantCtxKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant, its overrides are searched before the catalog
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantCtxKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant stored by WithTenant
+func TenantFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	tenant, ok := ctx.Value(tenantCtxKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// ctxScope returns the tenant overrides and the language of ctx
+func (b *Bundle) ctxScope(ctx context.Context) (*catalog, language.I18nLang) {
+	tenant, _ := TenantFromContext(ctx)
+	return b.tenantOverrides(tenant), b.langFromContext(ctx)
+}
+
+// transCtx follows the same calling convention as trans, with the language and the tenant of ctx
+func (b *Bundle) transCtx(ctx context.Context, key string) string {
+	tenant, lang := b.ctxScope(ctx)
+	return b.translate(tenant, lang, key, b.callerNamespace(key))
+}
+
+// transPluralCtx follows the same calling convention as trans
+func (b *Bundle) transPluralCtx(ctx context.Context, key string, count interface{}) string {
+	tenant, lang := b.ctxScope(ctx)
+	return b.translatePlural(tenant, lang, key, count, b.callerNamespace(key))
+}
+
+// transMsgCtx follows the same calling convention as trans
+func (b *Bundle) transMsgCtx(ctx context.Context, key string, args map[string]interface{}) string {
+	tenant, lang := b.ctxScope(ctx)
+	return b.translateMsg(tenant, lang, key, args, b.callerNamespace(key))
+}
+
+// transNamedCtx follows the same calling convention as trans
+func (b *Bundle) transNamedCtx(ctx context.Context, key string, params interface{}) string {
+	tenant, lang := b.ctxScope(ctx)
+	return b.translateNamed(tenant, lang, key, params, b.callerNamespace(key))
+}
+
+func (b *Bundle) TransCtx(ctx context.Context, key string) string {
+	return b.transCtx(ctx, key)
+}
+
+func (b *Bundle) TransfCtx(ctx context.Context, key string, a ...interface{}) string {
+	return fmt.Sprintf(b.transCtx(ctx, key), a...)
+}
+
+func (b *Bundle) TransPluralCtx(ctx context.Context, key string, count interface{}, a ...interface{}) string {
+	return fmt.Sprintf(b.transPluralCtx(ctx, key, count), a...)
+}
+
+func (b *Bundle) TransMsgCtx(ctx context.Context, key string, args map[string]interface{}) string {
+	return b.transMsgCtx(ctx, key, args)
+}
+
+func (b *Bundle) TransNamedCtx(ctx context.Context, key string, params interface{}) string {
+	return b.transNamedCtx(ctx, key, params)
+}
+
+func TransCtx(ctx context.Context, key string) string {
+	if b := DefaultBundle(); b == nil {
+		panic("i18n is not initialized.")
+	} else {
+		return b.transCtx(ctx, key)
+	}
+}
+
+func TransfCtx(ctx context.Context, key string, a ...interface{}) string {
+	if b := DefaultBundle(); b == nil {
+		panic("i18n is not initialized.")
+	} else {
+		return fmt.Sprintf(b.transCtx(ctx, key), a...)
+	}
+}
+
+func TransPluralCtx(ctx context.Context, key string, count interface{}, a ...interface{}) string {
+	if b := DefaultBundle(); b == nil {
+		panic("i18n is not initialized.")
+	} else {
+		return fmt.Sprintf(b.transPluralCtx(ctx, key, count), a...)
+	}
+}
+
+func TransMsgCtx(ctx context.Context, key string, args map[string]interface{}) string {
+	if b := DefaultBundle(); b == nil {
+		panic("i18n is not initialized.")
+	} else {
+		return b.transMsgCtx(ctx, key, args)
+	}
+}
+
+func TransNamedCtx(ctx context.Context, key string, params interface{}) string {
+	if b := DefaultBundle(); b == nil {
+		panic("i18n is not initialized.")
+	} else {
+		return b.transNamedCtx(ctx, key, params)
+	}
+}
@@ -1,15 +1,130 @@
 package i18n
 
-import "fmt"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yaou-li/go-i18n/language"
+)
 
 type dict map[string]string
 
 type dictWithNamespace map[string]dict
 
+// plural holds the translation of each CLDR plural category
+type plural map[language.PluralCategory]string
+
+type pluralDict map[string]plural
+
+type pluralDictWithNamespace map[string]pluralDict
+
 type I18nDict struct {
-	Lang      string `json:"language"`
-	Namespace string `json:"namespace,omitempty"`
-	Dict      dict   `json:"dict"`
+	Lang      string     `json:"language"`
+	Namespace string     `json:"namespace,omitempty"`
+	Dict      dict       `json:"dict"`
+	Plural    pluralDict `json:"-"`
+	// translator comments of the keys, the empty key holds the file comment
+	Comments map[string]string `json:"-"`
+	// source references of the keys, as file:line
+	References map[string][]string `json:"-"`
+	// gettext flags of the keys, fuzzy translations are not loaded
+	Flags map[string][]string `json:"-"`
+	// gettext messages of the keys read from po and mo files, to write back their msgctxt and msgid_plural
+	Gettext map[string]GettextMessage `json:"-"`
+	// Plural-Forms header of po and mo files, it maps the msgstr indexes to the plural categories
+	PluralForms string `json:"-"`
+}
+
+// GettextMessage is the original msgctxt, msgid and msgid_plural of a key
+type GettextMessage struct {
+	Context  string
+	ID       string
+	IDPlural string
+}
+
+// pluralOrder is the CLDR order of the plural categories
+var pluralOrder = []language.PluralCategory{
+	language.PluralZero,
+	language.PluralOne,
+	language.PluralTwo,
+	language.PluralFew,
+	language.PluralMany,
+	language.PluralOther,
+}
+
+/**
+* UnmarshalJSON reads the layout of the json files the same way as the json format,
+* nested objects are joined with the default splitter "."
+**/
+func (d *I18nDict) UnmarshalJSON(data []byte) error {
+	nd, err := jsonFormat{}.Decode(data, FormatFile{Splitter: "."})
+	if err != nil {
+		return err
+	}
+	*d = *nd
+	return nil
+}
+
+func (d I18nDict) MarshalJSON() ([]byte, error) {
+	entries := make(map[string]interface{}, len(d.Dict)+len(d.Plural))
+	for key, val := range d.Dict {
+		entries[key] = val
+	}
+	for key, forms := range d.Plural {
+		entries[key] = forms
+	}
+	return json.Marshal(struct {
+		Lang      string                 `json:"language"`
+		Namespace string                 `json:"namespace,omitempty"`
+		Dict      map[string]interface{} `json:"dict"`
+	}{d.Lang, d.Namespace, entries})
+}
+
+// MarshalJSON keeps the categories in CLDR order
+func (p plural) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for _, category := range pluralOrder {
+		form, ok := p[category]
+		if !ok {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		val, err := json.Marshal(form)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(`"` + string(category) + `":`)
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// get returns the form of the category, falling back to other
+func (p plural) get(category language.PluralCategory) (string, bool) {
+	if val, ok := p[category]; ok && val != "" {
+		return val, true
+	}
+	if val, ok := p[language.PluralOther]; ok && val != "" {
+		return val, true
+	}
+	return "", false
+}
+
+func (p plural) clone() plural {
+	np := make(plural, len(p))
+	for category, val := range p {
+		np[category] = val
+	}
+	return np
 }
 
 func (d *I18nDict) Merge(ndict *I18nDict) error {
@@ -21,6 +136,15 @@ func (d *I18nDict) Merge(ndict *I18nDict) error {
 			d.Dict[key] = val
 		}
 	}
+	if len(ndict.Plural) > 0 && d.Plural == nil {
+		d.Plural = make(pluralDict)
+	}
+	for key, forms := range ndict.Plural {
+		if _, ok := d.Plural[key]; !ok {
+			d.Plural[key] = forms.clone()
+		}
+	}
+	d.mergeComments(ndict, false)
 	return nil
 }
 
@@ -31,17 +155,190 @@ func (d *I18nDict) Overwrite(ndict *I18nDict) error {
 	for key, val := range ndict.Dict {
 		d.Dict[key] = val
 	}
+	if len(ndict.Plural) > 0 && d.Plural == nil {
+		d.Plural = make(pluralDict)
+	}
+	for key, forms := range ndict.Plural {
+		if _, ok := d.Plural[key]; !ok {
+			d.Plural[key] = make(plural)
+		}
+		for category, val := range forms {
+			d.Plural[key][category] = val
+		}
+	}
+	d.mergeComments(ndict, true)
 	return nil
 }
 
+/**
+* mergeComments merges the comments, the flags and the gettext metadata,
+* the references describe the source code so they are never overwritten
+**/
+func (d *I18nDict) mergeComments(ndict *I18nDict, overwrite bool) {
+	if len(ndict.Comments) > 0 && d.Comments == nil {
+		d.Comments = make(map[string]string)
+	}
+	for key, comment := range ndict.Comments {
+		if _, ok := d.Comments[key]; !ok || overwrite {
+			d.Comments[key] = comment
+		}
+	}
+	if len(ndict.Flags) > 0 && d.Flags == nil {
+		d.Flags = make(map[string][]string)
+	}
+	for key, flags := range ndict.Flags {
+		if _, ok := d.Flags[key]; !ok || overwrite {
+			d.Flags[key] = append([]string(nil), flags...)
+		}
+	}
+	if len(ndict.References) > 0 && d.References == nil {
+		d.References = make(map[string][]string)
+	}
+	for key, refs := range ndict.References {
+		if _, ok := d.References[key]; !ok {
+			d.References[key] = append([]string(nil), refs...)
+		}
+	}
+	if len(ndict.Gettext) > 0 && d.Gettext == nil {
+		d.Gettext = make(map[string]GettextMessage)
+	}
+	for key, msg := range ndict.Gettext {
+		if _, ok := d.Gettext[key]; !ok || overwrite {
+			d.Gettext[key] = msg
+		}
+	}
+	if ndict.PluralForms != "" && (d.PluralForms == "" || overwrite) {
+		d.PluralForms = ndict.PluralForms
+	}
+}
+
+// IsFuzzy reports whether the translation of key is flagged as fuzzy
+func (d *I18nDict) IsFuzzy(key string) bool {
+	for _, flag := range d.Flags[key] {
+		if flag == "fuzzy" {
+			return true
+		}
+	}
+	return false
+}
+
 func (d *I18nDict) Clone() *I18nDict {
 	nd := &I18nDict{
 		Lang:      d.Lang,
 		Namespace: d.Namespace,
 		Dict:      make(dict),
+		Plural:    make(pluralDict),
 	}
 	for k, v := range d.Dict {
 		nd.Dict[k] = v
 	}
+	for k, v := range d.Plural {
+		nd.Plural[k] = v.clone()
+	}
+	nd.mergeComments(d, true)
 	return nd
 }
+
+/**
+* flattenMap adds the entries of a decoded nested map to the dict,
+* nested keys are joined with the splitter and a map holding only plural categories is a plural entry,
+* the other category is required so a nested group like {"one": ..., "two": ...} stays plain keys
+**/
+func flattenMap(entries map[string]interface{}, prefix string, splitter string, d *I18nDict) error {
+	for key, val := range entries {
+		if prefix != "" {
+			key = prefix + splitter + key
+		}
+		switch v := val.(type) {
+		case string:
+			d.Dict[key] = v
+		case map[string]interface{}:
+			if forms, ok := pluralMap(v); ok {
+				d.Plural[key] = forms
+			} else if err := flattenMap(v, key, splitter, d); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("Invalid translation of key: %v, expect a string or a map", key)
+		}
+	}
+	return nil
+}
+
+/**
+* nestMap is the reverse of flattenMap, the keys are split with the splitter into nested maps,
+* a key whose path is taken by a string stays flat at that level so the maps flatten back to the same keys
+**/
+func nestMap(d *I18nDict, splitter string) map[string]interface{} {
+	keys := make([]string, 0, len(d.Dict)+len(d.Plural))
+	for key := range d.Dict {
+		if _, ok := d.Plural[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	for key := range d.Plural {
+		keys = append(keys, key)
+	}
+	// a key is inserted before the keys it prefixes
+	sort.Strings(keys)
+	root := make(map[string]interface{})
+	for _, key := range keys {
+		var val interface{} = d.Dict[key]
+		if forms, ok := d.Plural[key]; ok {
+			val = forms
+		}
+		parts := []string{key}
+		if splitter != "" {
+			parts = strings.Split(key, splitter)
+		}
+		node := root
+		i := 0
+		for ; i < len(parts)-1; i++ {
+			child, ok := node[parts[i]].(map[string]interface{})
+			if !ok {
+				if _, taken := node[parts[i]]; taken {
+					break
+				}
+				child = make(map[string]interface{})
+				node[parts[i]] = child
+			}
+			node = child
+		}
+		node[strings.Join(parts[i:], splitter)] = val
+	}
+	unnestPlurals(root, splitter)
+	return root
+}
+
+// unnestPlurals flattens the nested maps holding only plural category keys, they would be read as plural entries
+func unnestPlurals(node map[string]interface{}, splitter string) {
+	for key, val := range node {
+		child, ok := val.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		unnestPlurals(child, splitter)
+		if _, ok := pluralMap(child); ok {
+			delete(node, key)
+			for category, form := range child {
+				node[key+splitter+category] = form
+			}
+		}
+	}
+}
+
+// pluralMap reads the map as a plural entry when its keys are plural categories with the other category among them
+func pluralMap(entries map[string]interface{}) (plural, bool) {
+	if _, ok := entries[string(language.PluralOther)]; !ok {
+		return nil, false
+	}
+	forms := make(plural)
+	for key, val := range entries {
+		form, ok := val.(string)
+		if !ok || !language.IsPluralCategory(key) {
+			return nil, false
+		}
+		forms[language.PluralCategory(key)] = form
+	}
+	return forms, true
+}
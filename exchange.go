@@ -0,0 +1,118 @@
+package i18n
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+// Catalogs are the dicts of several languages, keyed by language and then by namespace without the language
+type Catalogs map[language.I18nLang]map[string]*I18nDict
+
+/**
+* ExchangeFormat moves the catalogs of every language in and out of the files of other tools,
+* like the xliff documents of the translators or the android and ios resources,
+* the exchange formats are registered by name with RegisterExchangeFormat
+**/
+type ExchangeFormat interface {
+	// Export writes the catalogs into dir
+	Export(opts *I18nOpts, catalogs Catalogs, dir string) error
+	// Import reads the translations of fpath, a file or a directory, catalogs are the existing ones
+	Import(opts *I18nOpts, catalogs Catalogs, fpath string) (Catalogs, error)
+}
+
+var (
+	exchangeLock    sync.RWMutex
+	exchangeFormats = make(map[string]ExchangeFormat)
+)
+
+func init() {
+	RegisterExchangeFormat(xliffExchangeName(XLIFF12), xliffExchange{XLIFF12})
+	RegisterExchangeFormat(xliffExchangeName(XLIFF20), xliffExchange{XLIFF20})
+	RegisterExchangeFormat(Android, mobileExchange{Android})
+	RegisterExchangeFormat(IOS, mobileExchange{IOS})
+}
+
+// RegisterExchangeFormat registers an exchange format, the name is case insensitive and replaces any existing format
+func RegisterExchangeFormat(name string, format ExchangeFormat) {
+	exchangeLock.Lock()
+	defer exchangeLock.Unlock()
+	exchangeFormats[strings.ToLower(name)] = format
+}
+
+// LookupExchangeFormat returns the exchange format registered for the name
+func LookupExchangeFormat(name string) (ExchangeFormat, error) {
+	exchangeLock.RLock()
+	defer exchangeLock.RUnlock()
+	if format, ok := exchangeFormats[strings.ToLower(name)]; ok {
+		return format, nil
+	}
+	return nil, fmt.Errorf("Unsupported exchange format: %v", name)
+}
+
+// ExchangeFormats lists the registered exchange formats
+func ExchangeFormats() []string {
+	exchangeLock.RLock()
+	defer exchangeLock.RUnlock()
+	res := make([]string, 0, len(exchangeFormats))
+	for name := range exchangeFormats {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func writeExchangeFile(fpath string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fpath, data, os.ModePerm)
+}
+
+// xliffExchangeName is the exchange format name of an xliff version, e.g. xliff1.2
+func xliffExchangeName(version string) string {
+	return "xliff" + version
+}
+
+// xliffExchange writes one <lang>.xlf document for each target language
+type xliffExchange struct {
+	version string
+}
+
+func (x xliffExchange) Export(opts *I18nOpts, catalogs Catalogs, dir string) error {
+	for _, lang := range opts.langs {
+		if lang == opts.src {
+			continue
+		}
+		data, err := EncodeXLIFF(x.version, opts.src, lang, catalogs[opts.src], catalogs[lang])
+		if err != nil {
+			return err
+		}
+		if err := writeExchangeFile(filepath.Join(dir, lang.Shortcut()+".xlf"), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Import reads a document of either version
+func (xliffExchange) Import(opts *I18nOpts, catalogs Catalogs, fpath string) (Catalogs, error) {
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	lang, dicts, err := DecodeXLIFF(data)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.IsEnabled(lang) {
+		return nil, fmt.Errorf("Unsupported language: %v", lang)
+	}
+	return Catalogs{language.GetLang(lang): dicts}, nil
+}
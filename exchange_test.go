@@ -0,0 +1,154 @@
+package i18n
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+type testExchange struct {
+	exported Catalogs
+}
+
+func (x *testExchange) Export(opts *I18nOpts, catalogs Catalogs, dir string) error {
+	x.exported = catalogs
+	return nil
+}
+
+func (x *testExchange) Import(opts *I18nOpts, catalogs Catalogs, fpath string) (Catalogs, error) {
+	return Catalogs{language.Korean: {"index": {Dict: dict{"hello": fpath}}}}, nil
+}
+
+func TestExchangeRegistry(t *testing.T) {
+	for _, name := range []string{"xliff1.2", "XLIFF2.0", "android", "iOS"} {
+		if _, err := LookupExchangeFormat(name); err != nil {
+			t.Errorf("LookupExchangeFormat(%v) error: %v", name, err)
+		}
+	}
+	if _, err := LookupExchangeFormat("csv"); err == nil {
+		t.Errorf("LookupExchangeFormat(csv) succeeded")
+	}
+	RegisterExchangeFormat("Test", &testExchange{})
+	if _, err := LookupExchangeFormat("test"); err != nil {
+		t.Errorf("LookupExchangeFormat(test) error: %v", err)
+	}
+	got := ExchangeFormats()
+	if !sort.StringsAreSorted(got) {
+		t.Errorf("ExchangeFormats() = %v, want sorted", got)
+	}
+	registered := make(map[string]bool)
+	for _, name := range got {
+		registered[name] = true
+	}
+	for _, name := range []string{"android", "ios", "test", "xliff1.2", "xliff2.0"} {
+		if !registered[name] {
+			t.Errorf("ExchangeFormats() = %v, missing %v", got, name)
+		}
+	}
+}
+
+func newTestWriter(t *testing.T, dir string) I18nWriter {
+	t.Helper()
+	opts := NewI18nOpts()
+	opts.SetEnableLangs("en,ko")
+	opts.SetTargetLang("en")
+	opts.SetLanguageDir(dir)
+	return NewWriter(opts, map[string]*I18nDict{
+		"en.index": {Lang: "en", Dict: dict{"hello": "Hello", "bye": "Bye"}},
+		"ko.index": {Lang: "ko", Dict: dict{"hello": "안녕하세요", "bye": ""}},
+	})
+}
+
+func TestWriterExchange(t *testing.T) {
+	x := &testExchange{}
+	RegisterExchangeFormat("writer-test", x)
+	w := newTestWriter(t, t.TempDir())
+	if err := w.Export("writer-test", "out"); err != nil {
+		t.Fatal(err)
+	}
+	if got := x.exported[language.Korean]["index"].Dict["hello"]; got != "안녕하세요" {
+		t.Errorf("exported ko hello = %v, want 안녕하세요", got)
+	}
+	if err := w.Import("writer-test", "imported"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Export("writer-test", "out"); err != nil {
+		t.Fatal(err)
+	}
+	// the imported translations are merged into the existing catalog
+	if got := x.exported[language.Korean]["index"].Dict; !reflect.DeepEqual(got, dict{"hello": "imported", "bye": ""}) {
+		t.Errorf("ko dict after import = %v", got)
+	}
+	if err := w.Export("csv", "out"); err == nil {
+		t.Errorf("Export(csv) succeeded")
+	}
+}
+
+func mustReadFile(t *testing.T, fpath string) []byte {
+	t.Helper()
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestXLIFFExchange(t *testing.T) {
+	dir := t.TempDir()
+	w := newTestWriter(t, dir)
+	out := filepath.Join(dir, "xliff")
+	if err := w.Export("xliff2.0", out); err != nil {
+		t.Fatal(err)
+	}
+	fpath := filepath.Join(out, "ko.xlf")
+	if _, err := os.Stat(filepath.Join(out, "en.xlf")); err == nil {
+		t.Errorf("the source language was exported")
+	}
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the translator fills bye in
+	data = regexp.MustCompile(`(<source>Bye</source>\s*)<target></target>`).ReplaceAll(data, []byte("${1}<target>안녕히 가세요</target>"))
+	if err := ioutil.WriteFile(fpath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Import("xliff2.0", fpath); err != nil {
+		t.Fatal(err)
+	}
+	got, err := jsonFormat{}.Decode(mustReadFile(t, filepath.Join(dir, "ko", "index.json")), FormatFile{Splitter: "."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (dict{"hello": "안녕하세요", "bye": "안녕히 가세요"}); !reflect.DeepEqual(got.Dict, want) {
+		t.Errorf("ko dict after import = %v, want %v", got.Dict, want)
+	}
+}
+
+func TestMobileExchange(t *testing.T) {
+	for _, platform := range []string{Android, IOS} {
+		dir := t.TempDir()
+		w := newTestWriter(t, dir)
+		out := filepath.Join(dir, platform)
+		if err := w.Export(platform, out); err != nil {
+			t.Fatalf("%v: %v", platform, err)
+		}
+		if err := w.Import(platform, out); err != nil {
+			t.Fatalf("%v: %v", platform, err)
+		}
+		got, err := jsonFormat{}.Decode(mustReadFile(t, filepath.Join(dir, "ko", "index.json")), FormatFile{Splitter: "."})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the untranslated keys are not exported, the apps fall back to the source language
+		if want := (dict{"hello": "안녕하세요", "bye": ""}); !reflect.DeepEqual(got.Dict, want) {
+			t.Errorf("%v: ko dict after import = %v, want %v", platform, got.Dict, want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package i18n
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yaou-li/go-i18n/language"
+)
+
+func TestFallbackChain(t *testing.T) {
+	opts := NewI18nOpts()
+	opts.SetEnableLangs("en,ja,ko")
+	opts.SetSrcLang("en")
+	opts.SetFallbacks("ko", "ja, unknown, ko")
+	tests := []struct {
+		lang language.I18nLang
+		want []language.I18nLang
+	}{
+		{language.Korean, []language.I18nLang{language.Korean, language.Japanese, language.English}},
+		{language.Japanese, []language.I18nLang{language.Japanese, language.English}},
+		{language.English, []language.I18nLang{language.English}},
+	}
+	for _, tt := range tests {
+		if got := opts.FallbackChain(tt.lang); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FallbackChain(%v) = %v, want %v", tt.lang.Shortcut(), got, tt.want)
+		}
+	}
+}
+
+func TestFallbackLookup(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "en.json", `{"language":"en","dict":{"hello":"Hello","bye":"Bye","files":{"one":"%d file","other":"%d files"}}}`)
+	writeTestFile(t, dir, "ja.json", `{"language":"ja","dict":{"hello":"こんにちは","files":{"other":"%d ファイル"}}}`)
+	writeTestFile(t, dir, "ko.json", `{"language":"ko","dict":{"bye":""}}`)
+	opts := NewI18nOpts()
+	opts.SetEnableLangs("en,ja,ko")
+	opts.SetSrcLang("en")
+	opts.SetTargetLang("ko")
+	opts.SetLanguageDir(dir)
+	opts.SetFallbacks("ko", "ja")
+	var served []string
+	opts.SetFallbackHook(func(key string, requested language.I18nLang, lang language.I18nLang) {
+		served = append(served, key+":"+requested.Shortcut()+"->"+lang.Shortcut())
+	})
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	b := NewBundle(opts, log)
+	if err := b.Load(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"hello", "こんにちは"},
+		// an empty translation falls back as well
+		{"bye", "Bye"},
+		{"missing", "missing"},
+	}
+	for _, tt := range tests {
+		if got := b.Trans(tt.key); got != tt.want {
+			t.Errorf("Trans(%v) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+	if got := b.TransPlural("files", 2, 2); got != "2 ファイル" {
+		t.Errorf("TransPlural(files) = %v, want the japanese plural", got)
+	}
+	want := []string{"hello:ko->ja", "bye:ko->en", "files:ko->ja"}
+	if !reflect.DeepEqual(served, want) {
+		t.Errorf("fallback hook calls = %v, want %v", served, want)
+	}
+}
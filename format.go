@@ -0,0 +1,114 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/**
+* Format reads and writes the translation files of a file type,
+* the formats are registered by file type with RegisterFormat
+**/
+type Format interface {
+	// Extensions lists the file extensions without the dot, files are written with the first one
+	Extensions() []string
+	Decode(data []byte, file FormatFile) (*I18nDict, error)
+	Encode(dict *I18nDict) ([]byte, error)
+}
+
+// TemplateFormat is a format writing a template of the extracted keys next to the catalogs, like gettext pot files
+type TemplateFormat interface {
+	Format
+	TemplateExtension() string
+	EncodeTemplate(dict *I18nDict) ([]byte, error)
+}
+
+// NestedFormat is a format able to write the keys as nested objects split by the splitter
+type NestedFormat interface {
+	Format
+	Nested(splitter string) Format
+}
+
+// CompiledFormat is a format compiled out of the catalogs, like gettext mo files, the extracted keys are never written with it
+type CompiledFormat interface {
+	Format
+	Compiled() bool
+}
+
+// IsCompiled reports whether the format of the file type is only compiled out of the catalogs
+func IsCompiled(fileType string) bool {
+	format, err := LookupFormat(fileType)
+	if err != nil {
+		return false
+	}
+	compiled, ok := format.(CompiledFormat)
+	return ok && compiled.Compiled()
+}
+
+/**
+* FormatFile describes the file being decoded,
+* the language and the namespace are derived from the path, the content of the file takes precedence,
+* the namespace is empty unless namespace mode is enabled
+**/
+type FormatFile struct {
+	Path      string
+	Lang      string
+	Namespace string
+	Splitter  string
+}
+
+var (
+	formatLock sync.RWMutex
+	formats    = make(map[string]Format)
+)
+
+func init() {
+	RegisterFormat("json", jsonFormat{})
+	RegisterFormat("yaml", yamlFormat{"yaml"})
+	RegisterFormat("yml", yamlFormat{"yml"})
+	RegisterFormat("toml", tomlFormat{})
+	RegisterFormat("po", poFormat{})
+	RegisterFormat("mo", moFormat{})
+}
+
+// RegisterFormat registers the format of a file type, the file type is case insensitive and replaces any existing format
+func RegisterFormat(fileType string, format Format) {
+	formatLock.Lock()
+	defer formatLock.Unlock()
+	formats[strings.ToLower(fileType)] = format
+}
+
+// LookupFormat returns the format registered for the file type
+func LookupFormat(fileType string) (Format, error) {
+	formatLock.RLock()
+	defer formatLock.RUnlock()
+	if format, ok := formats[strings.ToLower(fileType)]; ok {
+		return format, nil
+	}
+	return nil, fmt.Errorf("Unsupported file type: %v", fileType)
+}
+
+// ExtractFormats lists the registered file types the extractor can write, the compiled formats are left out
+func ExtractFormats() []string {
+	var res []string
+	for _, fileType := range Formats() {
+		if !IsCompiled(fileType) {
+			res = append(res, fileType)
+		}
+	}
+	return res
+}
+
+// Formats lists the registered file types
+func Formats() []string {
+	formatLock.RLock()
+	defer formatLock.RUnlock()
+	res := make([]string, 0, len(formats))
+	for fileType := range formats {
+		res = append(res, fileType)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,48 @@
+package i18n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatRegistry(t *testing.T) {
+	tests := []struct {
+		fileType   string
+		extensions []string
+		compiled   bool
+	}{
+		{"json", []string{"json"}, false},
+		{"YAML", []string{"yaml", "yml"}, false},
+		{"yml", []string{"yml", "yaml"}, false},
+		{"toml", []string{"toml"}, false},
+		{"po", []string{"po"}, false},
+		{"mo", []string{"mo"}, true},
+	}
+	for _, tt := range tests {
+		format, err := LookupFormat(tt.fileType)
+		if err != nil {
+			t.Errorf("LookupFormat(%v) error: %v", tt.fileType, err)
+			continue
+		}
+		if got := format.Extensions(); !reflect.DeepEqual(got, tt.extensions) {
+			t.Errorf("%v.Extensions() = %v, want %v", tt.fileType, got, tt.extensions)
+		}
+		if got := IsCompiled(tt.fileType); got != tt.compiled {
+			t.Errorf("IsCompiled(%v) = %v, want %v", tt.fileType, got, tt.compiled)
+		}
+	}
+	if _, err := LookupFormat("ini"); err == nil {
+		t.Errorf("LookupFormat(ini) succeeded")
+	}
+	for _, fileType := range ExtractFormats() {
+		if fileType == "mo" {
+			t.Errorf("ExtractFormats() = %v, contains the compiled mo", ExtractFormats())
+		}
+	}
+	if _, ok := interface{}(poFormat{}).(TemplateFormat); !ok {
+		t.Errorf("po is not a template format")
+	}
+	if _, ok := interface{}(jsonFormat{}).(NestedFormat); !ok {
+		t.Errorf("json is not a nested format")
+	}
+}
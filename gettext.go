@@ -0,0 +1,441 @@
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+/**
+* gettextPlural maps the msgstr[n] indexes of gettext catalogs to the CLDR categories,
+* gettext has no form for the fractions so some languages use fewer forms than CLDR
+**/
+type gettextPlural struct {
+	// Plural-Forms header
+	forms string
+	// category of each msgstr index
+	categories []language.PluralCategory
+	// the indexes no CLDR category is left for, they are read and written as other
+	unclaimed map[int]bool
+}
+
+var (
+	gettextPluralOther = gettextPlural{
+		forms:      "nplurals=1; plural=0;",
+		categories: []language.PluralCategory{language.PluralOther},
+	}
+	gettextPluralOneN = gettextPlural{
+		forms:      "nplurals=2; plural=(n != 1);",
+		categories: []language.PluralCategory{language.PluralOne, language.PluralOther},
+	}
+	gettextPluralOneN01 = gettextPlural{
+		forms:      "nplurals=2; plural=(n > 1);",
+		categories: []language.PluralCategory{language.PluralOne, language.PluralOther},
+	}
+	gettextPluralSlavic = gettextPlural{
+		forms:      "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+		categories: []language.PluralCategory{language.PluralOne, language.PluralFew, language.PluralMany},
+	}
+	gettextPluralCroatian = gettextPlural{
+		forms:      gettextPluralSlavic.forms,
+		categories: []language.PluralCategory{language.PluralOne, language.PluralFew, language.PluralOther},
+	}
+	gettextPluralCzech = gettextPlural{
+		forms:      "nplurals=3; plural=(n==1 ? 0 : n>=2 && n<=4 ? 1 : 2);",
+		categories: []language.PluralCategory{language.PluralOne, language.PluralFew, language.PluralOther},
+	}
+)
+
+// gettextPlurals is keyed by language tag, then by base language
+var gettextPlurals = map[string]gettextPlural{
+	"am":    gettextPluralOneN01,
+	"bn":    gettextPluralOneN01,
+	"fa":    gettextPluralOneN01,
+	"fr":    gettextPluralOneN01,
+	"gu":    gettextPluralOneN01,
+	"hi":    gettextPluralOneN01,
+	"hy":    gettextPluralOneN01,
+	"kn":    gettextPluralOneN01,
+	"pa":    gettextPluralOneN01,
+	"pt":    gettextPluralOneN01,
+	"pt-PT": gettextPluralOneN,
+	"si":    gettextPluralOneN01,
+	"zu":    gettextPluralOneN01,
+	"is": {
+		forms:      "nplurals=2; plural=(n%10!=1 || n%100==11);",
+		categories: []language.PluralCategory{language.PluralOne, language.PluralOther},
+	},
+	"mk": {
+		forms:      "nplurals=2; plural=(n%10!=1 || n%100==11);",
+		categories: []language.PluralCategory{language.PluralOne, language.PluralOther},
+	},
+	"fil": {
+		forms:      "nplurals=2; plural=(n!=1 && n!=2 && n!=3 && (n%10==4 || n%10==6 || n%10==9));",
+		categories: []language.PluralCategory{language.PluralOne, language.PluralOther},
+	},
+	"ru": gettextPluralSlavic,
+	"uk": gettextPluralSlavic,
+	"pl": {
+		forms:      "nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+		categories: []language.PluralCategory{language.PluralOne, language.PluralFew, language.PluralMany},
+	},
+	"hr": gettextPluralCroatian,
+	"sr": gettextPluralCroatian,
+	"cs": gettextPluralCzech,
+	"sk": gettextPluralCzech,
+	"lt": {
+		forms:      "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && (n%100<10 || n%100>=20) ? 1 : 2);",
+		categories: []language.PluralCategory{language.PluralOne, language.PluralFew, language.PluralOther},
+	},
+	"lv": {
+		forms:      "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n != 0 ? 1 : 2);",
+		categories: []language.PluralCategory{language.PluralOne, language.PluralOther, language.PluralZero},
+	},
+	"ro": {
+		forms:      "nplurals=3; plural=(n==1 ? 0 : (n==0 || (n%100 > 0 && n%100 < 20)) ? 1 : 2);",
+		categories: []language.PluralCategory{language.PluralOne, language.PluralFew, language.PluralOther},
+	},
+	"he": {
+		forms:      "nplurals=3; plural=(n==1 ? 0 : n==2 ? 1 : 2);",
+		categories: []language.PluralCategory{language.PluralOne, language.PluralTwo, language.PluralOther},
+	},
+	"sl": {
+		forms:      "nplurals=4; plural=(n%100==1 ? 0 : n%100==2 ? 1 : n%100==3 || n%100==4 ? 2 : 3);",
+		categories: []language.PluralCategory{language.PluralOne, language.PluralTwo, language.PluralFew, language.PluralOther},
+	},
+	"ar": {
+		forms: "nplurals=6; plural=(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5);",
+		categories: []language.PluralCategory{
+			language.PluralZero, language.PluralOne, language.PluralTwo,
+			language.PluralFew, language.PluralMany, language.PluralOther,
+		},
+	},
+}
+
+// gettextPluralOf returns the gettext plural forms of lang, languages missing from the table use n != 1
+func gettextPluralOf(lang language.I18nLang) gettextPlural {
+	tag := lang.Shortcut()
+	if p, ok := gettextPlurals[tag]; ok {
+		return p
+	}
+	if p, ok := gettextPlurals[strings.SplitN(tag, "-", 2)[0]]; ok {
+		return p
+	}
+	if len(lang.PluralCategories()) == 1 {
+		return gettextPluralOther
+	}
+	return gettextPluralOneN
+}
+
+// toPlural converts the msgstr[n] forms to the CLDR categories
+func (p gettextPlural) toPlural(forms []string) (plural, error) {
+	if len(forms) > len(p.categories) {
+		return nil, fmt.Errorf("too many plural forms: %v, expect %v", len(forms), len(p.categories))
+	}
+	res := make(plural, len(forms))
+	for i, form := range forms {
+		// the index owning other takes precedence over the unclaimed ones
+		if _, ok := res[p.categories[i]]; ok && p.unclaimed[i] {
+			continue
+		}
+		res[p.categories[i]] = form
+	}
+	// the last form also serves the categories gettext has no form for
+	if _, ok := res[language.PluralOther]; !ok && len(forms) > 0 {
+		res[language.PluralOther] = forms[len(forms)-1]
+	}
+	return res, nil
+}
+
+// fromPlural returns the msgstr[n] forms of the CLDR categories
+func (p gettextPlural) fromPlural(forms plural) []string {
+	res := make([]string, len(p.categories))
+	for i, category := range p.categories {
+		res[i] = forms[category]
+	}
+	return res
+}
+
+/**
+* parsePluralForms reads a Plural-Forms header, e.g. "nplurals=2; plural=(n > 1);",
+* the msgstr indexes are mapped to the CLDR categories of lang by evaluating the expression on sample numbers,
+* each index takes the category most of its numbers have in CLDR, the index with the most numbers wins a category
+* claimed twice, the indexes left without a category are mapped to other, e.g. nplurals=2 for ja
+**/
+func parsePluralForms(header string, lang language.I18nLang) (gettextPlural, error) {
+	var (
+		nplurals = -1
+		expr     string
+	)
+	for _, field := range strings.Split(header, ";") {
+		idx := strings.Index(field, "=")
+		if idx < 0 {
+			continue
+		}
+		switch name, val := strings.TrimSpace(field[:idx]), strings.TrimSpace(field[idx+1:]); name {
+		case "nplurals":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return gettextPlural{}, fmt.Errorf("Invalid plural forms: %v", header)
+			}
+			nplurals = n
+		case "plural":
+			expr = val
+		}
+	}
+	if nplurals < 0 || expr == "" {
+		return gettextPlural{}, fmt.Errorf("Invalid plural forms: %v", header)
+	}
+	eval, err := parsePluralExpr(expr)
+	if err != nil {
+		return gettextPlural{}, fmt.Errorf("Invalid plural forms: %v, error: %v", header, err)
+	}
+	votes := make([]map[language.PluralCategory]int, nplurals)
+	for i := range votes {
+		votes[i] = make(map[language.PluralCategory]int)
+	}
+	for n := int64(0); n <= 1000; n++ {
+		idx := eval(n)
+		if idx < 0 || idx >= int64(nplurals) {
+			return gettextPlural{}, fmt.Errorf("Invalid plural forms: %v, index %v out of range for n = %v", header, idx, n)
+		}
+		category, _ := lang.PluralCategory(n)
+		votes[idx][category]++
+	}
+	p := gettextPlural{forms: strings.TrimSpace(header), categories: make([]language.PluralCategory, nplurals)}
+	owners := make(map[language.PluralCategory]int)
+	pending := make([]int, nplurals)
+	for i := range pending {
+		pending[i] = i
+	}
+	// an index taking the category of another one with more numbers sends it back to pending
+	for len(pending) > 0 {
+		i := pending[0]
+		pending = pending[1:]
+		best, bestVotes := language.PluralCategory(""), 0
+		for _, category := range pluralOrder {
+			if owner, ok := owners[category]; ok && votes[owner][category] >= votes[i][category] {
+				continue
+			}
+			if votes[i][category] > bestVotes {
+				best, bestVotes = category, votes[i][category]
+			}
+		}
+		if best == "" {
+			continue
+		}
+		if owner, ok := owners[best]; ok {
+			p.categories[owner] = ""
+			pending = append(pending, owner)
+		}
+		owners[best] = i
+		p.categories[i] = best
+	}
+	for i, category := range p.categories {
+		if category == "" {
+			if p.unclaimed == nil {
+				p.unclaimed = make(map[int]bool)
+			}
+			p.unclaimed[i] = true
+			p.categories[i] = language.PluralOther
+		}
+	}
+	return p, nil
+}
+
+// gettextPluralOfHeader prefers the Plural-Forms header to the table, invalid headers are ignored
+func gettextPluralOfHeader(header string, lang language.I18nLang) gettextPlural {
+	if header != "" {
+		if p, err := parsePluralForms(header, lang); err == nil {
+			return p
+		}
+	}
+	return gettextPluralOf(lang)
+}
+
+type pluralExprParser struct {
+	src string
+	pos int
+}
+
+// parsePluralExpr compiles the C expression of a Plural-Forms header
+func parsePluralExpr(expr string) (func(n int64) int64, error) {
+	p := &pluralExprParser{src: expr}
+	eval, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected %q at offset %v", p.src[p.pos:], p.pos)
+	}
+	return eval, nil
+}
+
+func (p *pluralExprParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// accept consumes op if it is next, "<" does not accept the start of "<="
+func (p *pluralExprParser) accept(op string) bool {
+	p.skipSpace()
+	if !strings.HasPrefix(p.src[p.pos:], op) {
+		return false
+	}
+	if next := p.pos + len(op); len(op) == 1 && next < len(p.src) && p.src[next] == '=' && strings.ContainsAny(op, "<>!=") {
+		return false
+	}
+	p.pos += len(op)
+	return true
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (p *pluralExprParser) ternary() (func(int64) int64, error) {
+	cond, err := p.binary(0)
+	if err != nil || !p.accept("?") {
+		return cond, err
+	}
+	then, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept(":") {
+		return nil, fmt.Errorf("expect ':' at offset %v", p.pos)
+	}
+	otherwise, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return func(n int64) int64 {
+		if cond(n) != 0 {
+			return then(n)
+		}
+		return otherwise(n)
+	}, nil
+}
+
+// pluralExprLevels are the binary operators by increasing precedence
+var pluralExprLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<=", ">=", "<", ">"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *pluralExprParser) binary(level int) (func(int64) int64, error) {
+	if level == len(pluralExprLevels) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, candidate := range pluralExprLevels[level] {
+			if p.accept(candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		switch op {
+		case "||":
+			left = func(n int64) int64 { return boolInt(l(n) != 0 || right(n) != 0) }
+		case "&&":
+			left = func(n int64) int64 { return boolInt(l(n) != 0 && right(n) != 0) }
+		case "==":
+			left = func(n int64) int64 { return boolInt(l(n) == right(n)) }
+		case "!=":
+			left = func(n int64) int64 { return boolInt(l(n) != right(n)) }
+		case "<=":
+			left = func(n int64) int64 { return boolInt(l(n) <= right(n)) }
+		case ">=":
+			left = func(n int64) int64 { return boolInt(l(n) >= right(n)) }
+		case "<":
+			left = func(n int64) int64 { return boolInt(l(n) < right(n)) }
+		case ">":
+			left = func(n int64) int64 { return boolInt(l(n) > right(n)) }
+		case "+":
+			left = func(n int64) int64 { return l(n) + right(n) }
+		case "-":
+			left = func(n int64) int64 { return l(n) - right(n) }
+		case "*":
+			left = func(n int64) int64 { return l(n) * right(n) }
+		case "/":
+			left = func(n int64) int64 {
+				if r := right(n); r != 0 {
+					return l(n) / r
+				}
+				return 0
+			}
+		case "%":
+			left = func(n int64) int64 {
+				if r := right(n); r != 0 {
+					return l(n) % r
+				}
+				return 0
+			}
+		}
+	}
+}
+
+func (p *pluralExprParser) unary() (func(int64) int64, error) {
+	if p.accept("!") {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n int64) int64 { return boolInt(operand(n) == 0) }, nil
+	}
+	if p.accept("-") {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n int64) int64 { return -operand(n) }, nil
+	}
+	if p.accept("(") {
+		inner, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("expect ')' at offset %v", p.pos)
+		}
+		return inner, nil
+	}
+	if p.accept("n") {
+		return func(n int64) int64 { return n }, nil
+	}
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return nil, fmt.Errorf("unexpected end of expression at offset %v", p.pos)
+	}
+	v, err := strconv.ParseInt(p.src[start:p.pos], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return func(int64) int64 { return v }, nil
+}
@@ -1,5 +1,9 @@
 module github.com/yaou-li/go-i18n
 
-go 1.15
+go 1.16
 
-require github.com/sirupsen/logrus v1.7.0
+require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/sirupsen/logrus v1.7.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -2,30 +2,40 @@ package i18n
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
-	"runtime"
+	"io/fs"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/sirupsen/logrus"
 	"github.com/yaou-li/go-i18n/language"
 )
 
-var once sync.Once
-var i18nSingleton *i18n
-var i18nRuntimeDir string
-
 type I18nOpts struct {
-	target          language.I18nLang
+	// target holds the language.I18nLang, it is read and written atomically since UpdateLang may race with the lookups
+	target          uint32
 	src             language.I18nLang
 	langs           []language.I18nLang
 	splitter        string
 	dir             string
 	fileType        string
 	enableNamespace bool
+	fallbacks       map[language.I18nLang][]language.I18nLang
+	fallbackHook    FallbackHook
+	matcher         *language.Matcher
+	fsys            fs.FS
+	modulePath      string
+	nestedKeys      bool
+	layers          []Layer
+	tenantLoader    TenantLoader
+	tenantCapacity  int
+	// strictPlaceholders rejects the catalogs with mismatched placeholders
+	strictPlaceholders bool
 }
 
+// FallbackHook is called when a translation is served by another language than the requested one
+type FallbackHook func(key string, requested language.I18nLang, served language.I18nLang)
+
 func NewI18nOpts() *I18nOpts {
 	// only target lang and directory needs to be set specifically
 	defaultOpts := &I18nOpts{
@@ -34,6 +44,8 @@ func NewI18nOpts() *I18nOpts {
 		dir:             "./i18n",
 		fileType:        "json",
 		enableNamespace: false,
+		fallbacks:       make(map[language.I18nLang][]language.I18nLang),
+		tenantCapacity:  defaultTenantCapacity,
 	}
 	defaultOpts.SetEnableLangs("en,ko,zh,ru,ja")
 	return defaultOpts
@@ -51,13 +63,25 @@ func (opts *I18nOpts) SetEnableLangs(shortcuts string) {
 			opts.langs = append(opts.langs, language.GetLang(lang))
 		}
 	}
+	opts.matcher = language.NewMatcher(opts.langs)
+}
+
+/**
+* Match picks the best enabled language for the preferred tags, in order of preference,
+* e.g. en-AU matches en-GB and zh-HK matches zh-Hant
+**/
+func (opts *I18nOpts) Match(preferred ...string) (language.I18nLang, language.Confidence) {
+	if opts.matcher == nil {
+		return 0, language.No
+	}
+	return opts.matcher.MatchStrings(preferred...)
 }
 
 func (opts *I18nOpts) SetTargetLang(shortcut string) {
 	if !language.IsSupported(shortcut) {
 		panic(fmt.Sprintf("target language: %v is not supported", shortcut))
 	}
-	opts.target = language.GetLang(shortcut)
+	atomic.StoreUint32(&opts.target, uint32(language.GetLang(shortcut)))
 }
 
 func (opts *I18nOpts) SetSrcLang(shortcut string) {
@@ -79,10 +103,85 @@ func (opts *I18nOpts) SetFileType(fileType string) {
 	opts.fileType = fileType
 }
 
+/**
+* SetFS reads the translation files from fsys instead of the os file system,
+* the language directory is then a path inside fsys, e.g. with //go:embed i18n
+**/
+func (opts *I18nOpts) SetFS(fsys fs.FS) {
+	opts.fsys = fsys
+}
+
+// fileSystem returns the file system and the root of the translation files in it
+func (opts *I18nOpts) fileSystem() (fs.FS, string) {
+	return Layer{FS: opts.fsys, Dir: opts.dir}.fileSystem()
+}
+
+// SetModulePath sets the Go module path stripped from the import paths of namespace handles, the main module of the binary by default
+func (opts *I18nOpts) SetModulePath(modulePath string) {
+	opts.modulePath = strings.TrimSuffix(modulePath, "/")
+}
+
+// SetNestedKeys writes the keys as nested objects split by the splitter, for the formats supporting it like json
+func (opts *I18nOpts) SetNestedKeys(enable bool) {
+	opts.nestedKeys = enable
+}
+
+/**
+* SetStrictPlaceholders makes Load and Reload fail when a translation does not use the placeholders of the source language,
+* Reload then keeps the previous catalog, mismatches are only logged otherwise
+**/
+func (opts *I18nOpts) SetStrictPlaceholders(strict bool) {
+	opts.strictPlaceholders = strict
+}
+
 func (opts *I18nOpts) SetEnableNamespace(enable bool) {
 	opts.enableNamespace = enable
 }
 
+/**
+* SetFallbacks sets the languages tried after shortcut, in order,
+* e.g. SetFallbacks("pt-BR", "pt,en"), the source language is always tried last
+**/
+func (opts *I18nOpts) SetFallbacks(shortcut string, chain string) {
+	if !language.IsSupported(shortcut) {
+		panic(fmt.Sprintf("fallback language: %v is not supported", shortcut))
+	}
+	var fallbacks []language.I18nLang
+	for _, fallback := range strings.Split(chain, ",") {
+		fallback = strings.TrimSpace(fallback)
+		if language.IsSupported(fallback) {
+			fallbacks = append(fallbacks, language.GetLang(fallback))
+		}
+	}
+	opts.fallbacks[language.GetLang(shortcut)] = fallbacks
+}
+
+func (opts *I18nOpts) SetFallbackHook(hook FallbackHook) {
+	opts.fallbackHook = hook
+}
+
+/**
+* FallbackChain returns the languages to try for lang, starting with lang itself,
+* every language is followed by its parent locales and the source language comes last
+**/
+func (opts *I18nOpts) FallbackChain(lang language.I18nLang) []language.I18nLang {
+	var chain []language.I18nLang
+	seen := make(map[language.I18nLang]bool)
+	for _, fallback := range append(append([]language.I18nLang{lang}, opts.fallbacks[lang]...), opts.src) {
+		for ; fallback != 0 && !seen[fallback]; fallback = fallback.Parent() {
+			seen[fallback] = true
+			chain = append(chain, fallback)
+		}
+	}
+	return chain
+}
+
+func (opts *I18nOpts) reportFallback(key string, requested language.I18nLang, served language.I18nLang) {
+	if opts.fallbackHook != nil && requested != served {
+		opts.fallbackHook(key, requested, served)
+	}
+}
+
 func (opts *I18nOpts) IsEnabled(shortcut string) bool {
 	if !language.IsSupported(shortcut) {
 		return false
@@ -96,6 +195,14 @@ func (opts *I18nOpts) IsEnabled(shortcut string) bool {
 	return false
 }
 
+func (opts *I18nOpts) Langs() []language.I18nLang {
+	return append([]language.I18nLang(nil), opts.langs...)
+}
+
+func (opts *I18nOpts) GetTargetLang() language.I18nLang {
+	return language.I18nLang(atomic.LoadUint32(&opts.target))
+}
+
 func (opts *I18nOpts) IsNamespaced() bool {
 	return opts.enableNamespace
 }
@@ -108,89 +215,85 @@ func (opts *I18nOpts) GetSplitter() string {
 	return opts.splitter
 }
 
-type i18n struct {
-	opts   *I18nOpts
-	log    *logrus.Logger
-	loader *loader
-}
+var once sync.Once
+
+// defaultBundle holds the *Bundle behind the package level functions
+var defaultBundle atomic.Value
 
+// Init builds the default bundle used by the package level functions, only the first call takes effect
 func Init(opts *I18nOpts, log *logrus.Logger) {
 	once.Do(func() {
-		i18nSingleton = &i18n{
-			opts:   opts,
-			log:    log,
-			loader: Newloader(opts, log),
-		}
-		if dir, err := os.Getwd(); err != nil {
-			log.Error("Failed to get runtime folder.")
-		} else {
-			i18nRuntimeDir = dir
-		}
-		if err := i18nSingleton.loader.load(); err != nil {
+		bundle := NewBundle(opts, log)
+		if err := bundle.Load(); err != nil {
 			log.Errorf("Failed to load trans data, error: %v", err)
 		}
+		defaultBundle.Store(bundle)
 	})
 }
 
-func (i18n *i18n) GetLang() language.I18nLang {
-	return i18n.opts.target
+// SetDefaultBundle replaces the bundle behind the package level functions
+func SetDefaultBundle(bundle *Bundle) {
+	defaultBundle.Store(bundle)
 }
 
-func (i18n *i18n) GetShortcut() string {
-	return i18n.opts.target.Shortcut()
+// DefaultBundle returns the bundle behind the package level functions, nil before Init
+func DefaultBundle() *Bundle {
+	b, _ := defaultBundle.Load().(*Bundle)
+	return b
+}
+
+func GetDicts() map[language.I18nLang]dict {
+	if b := DefaultBundle(); b == nil {
+		return make(map[language.I18nLang]dict)
+	} else {
+		return b.GetDicts()
+	}
 }
 
-func (i18n *i18n) UpdateLang(shortcut string) {
-	if i18n.opts.IsEnabled(shortcut) {
-		i18n.opts.SetTargetLang(shortcut)
+func Trans(key string) string {
+	if b := DefaultBundle(); b == nil {
+		panic("i18n is not initialized.")
+	} else {
+		return b.trans(b.opts.GetTargetLang(), key)
 	}
 }
 
-func (i18n *i18n) trans(key string) string {
-	if i18n.opts.enableNamespace {
-		if _, fpath, _, ok := runtime.Caller(2); !ok {
-			i18n.log.Errorf("Failed to get caller of trans function, key: %v", key)
-			return i18n.loader.get(key)
-		} else {
-			namespace := i18n.opts.target.Shortcut() + "." + GetNamespace(filepath.Dir(fpath), i18nRuntimeDir, i18n.opts.splitter)
-			// fmt.Println("-----------")
-			// fmt.Println(filepath.Dir(fpath), i18nRuntimeDir, namespace)
-			// fmt.Println("-----------")
-			return i18n.loader.getWithNamespace(key, namespace)
-		}
+func Transf(key string, a ...interface{}) string {
+	if b := DefaultBundle(); b == nil {
+		panic("i18n is not initialized.")
 	} else {
-		return i18n.loader.get(key)
+		return fmt.Sprintf(b.trans(b.opts.GetTargetLang(), key), a...)
 	}
 }
 
-func GetDicts() map[language.I18nLang]dict {
-	if i18nSingleton == nil {
-		return make(map[language.I18nLang]dict)
+func TransPlural(key string, count interface{}, a ...interface{}) string {
+	if b := DefaultBundle(); b == nil {
+		panic("i18n is not initialized.")
 	} else {
-		return i18nSingleton.loader.dicts
+		return fmt.Sprintf(b.transPlural(b.opts.GetTargetLang(), key, count), a...)
 	}
 }
 
-func Trans(key string) string {
-	if i18nSingleton == nil {
+func TransMsg(key string, args map[string]interface{}) string {
+	if b := DefaultBundle(); b == nil {
 		panic("i18n is not initialized.")
 	} else {
-		return i18nSingleton.trans(key)
+		return b.transMsg(b.opts.GetTargetLang(), key, args)
 	}
 }
 
-func Transf(key string, a ...interface{}) string {
-	if i18nSingleton == nil {
+func TransNamed(key string, params interface{}) string {
+	if b := DefaultBundle(); b == nil {
 		panic("i18n is not initialized.")
 	} else {
-		return fmt.Sprintf(i18nSingleton.trans(key), a...)
+		return b.transNamed(b.opts.GetTargetLang(), key, params)
 	}
 }
 
 func UpdateLang(shortcut string) {
-	i18nSingleton.UpdateLang(shortcut)
+	DefaultBundle().UpdateLang(shortcut)
 }
 
 func GetLang() string {
-	return i18nSingleton.opts.target.Shortcut()
+	return DefaultBundle().GetShortcut()
 }
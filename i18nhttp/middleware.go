@@ -0,0 +1,92 @@
+package i18nhttp
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/yaou-li/go-i18n"
+	"github.com/yaou-li/go-i18n/language"
+)
+
+/**
+* Negotiator picks the language of a request among the enabled languages,
+* in order: the query parameter, the cookie and the Accept-Language header
+**/
+type Negotiator struct {
+	opts *i18n.I18nOpts
+	// QueryParam is the query parameter overriding the language, empty to disable
+	QueryParam string
+	// CookieName is the cookie overriding the language, empty to disable
+	CookieName string
+	// MinConfidence is the lowest match confidence accepted, High by default
+	MinConfidence language.Confidence
+}
+
+func NewNegotiator(opts *i18n.I18nOpts) *Negotiator {
+	return &Negotiator{
+		opts:          opts,
+		QueryParam:    "lang",
+		CookieName:    "lang",
+		MinConfidence: language.High,
+	}
+}
+
+// Middleware stores the negotiated language in the request context for i18n.TransCtx
+func Middleware(opts *i18n.I18nOpts) func(http.Handler) http.Handler {
+	return NewNegotiator(opts).Handler
+}
+
+// Negotiate returns the shortcut of the chosen language, false if nothing matched
+func (n *Negotiator) Negotiate(r *http.Request) (string, bool) {
+	if n.QueryParam != "" {
+		if shortcut, ok := n.match(r.URL.Query().Get(n.QueryParam)); ok {
+			return shortcut, true
+		}
+	}
+	if n.CookieName != "" {
+		if cookie, err := r.Cookie(n.CookieName); err == nil {
+			if shortcut, ok := n.match(cookie.Value); ok {
+				return shortcut, true
+			}
+		}
+	}
+	// the malformed ranges are skipped, the valid ones are still matched
+	accepted, _ := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	tags := make([]string, 0, len(accepted))
+	for _, al := range accepted {
+		tags = append(tags, al.Tag)
+	}
+	return n.match(tags...)
+}
+
+// match uses the language matcher of the options, "*" never matches
+func (n *Negotiator) match(tags ...string) (string, bool) {
+	var preferred []string
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" && tag != "*" {
+			preferred = append(preferred, tag)
+		}
+	}
+	if len(preferred) == 0 {
+		return "", false
+	}
+	lang, conf := n.opts.Match(preferred...)
+	if conf == language.No || conf < n.MinConfidence {
+		return "", false
+	}
+	return lang.Shortcut(), true
+}
+
+func (n *Negotiator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Language")
+		if n.CookieName != "" {
+			w.Header().Add("Vary", "Cookie")
+		}
+		if shortcut, ok := n.Negotiate(r); ok {
+			w.Header().Set("Content-Language", language.GetLang(shortcut).Shortcut())
+			r = r.WithContext(i18n.WithLang(r.Context(), shortcut))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,76 @@
+package i18nhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/yaou-li/go-i18n"
+)
+
+func TestMiddleware(t *testing.T) {
+	opts := i18n.NewI18nOpts()
+	opts.SetEnableLangs("en,ko,ja")
+	tests := []struct {
+		name   string
+		query  string
+		cookie string
+		accept string
+		want   string
+	}{
+		{"query first", "?lang=ja", "ko", "en", "ja"},
+		{"cookie before header", "", "ko", "ja, en;q=0.9", "ko"},
+		{"header quality", "", "", "en;q=0.5, ko-KR;q=0.8", "ko"},
+		{"unsupported query", "?lang=xx", "", "ja", "ja"},
+		{"malformed range skipped", "", "", "en;q=abc, ja;q=0.3", "ja"},
+		{"wildcard only", "", "", "*", ""},
+		{"nothing matched", "", "", "fr", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := Middleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if lang, ok := i18n.LangFromContext(r.Context()); ok {
+					got = lang.Shortcut()
+				}
+			}))
+			r := httptest.NewRequest("GET", "/"+tt.query, nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+			}
+			if tt.accept != "" {
+				r.Header.Set("Accept-Language", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if got != tt.want {
+				t.Errorf("language = %q, want %q", got, tt.want)
+			}
+			if lang := w.Header().Get("Content-Language"); lang != tt.want {
+				t.Errorf("Content-Language = %q, want %q", lang, tt.want)
+			}
+			if vary := w.Header().Values("Vary"); !reflect.DeepEqual(vary, []string{"Accept-Language", "Cookie"}) {
+				t.Errorf("Vary = %v, want Accept-Language and Cookie", vary)
+			}
+		})
+	}
+}
+
+func TestNegotiatorWithoutCookie(t *testing.T) {
+	opts := i18n.NewI18nOpts()
+	opts.SetEnableLangs("en,ko")
+	n := NewNegotiator(opts)
+	n.CookieName = ""
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "lang", Value: "ko"})
+	r.Header.Set("Accept-Language", "en")
+	w := httptest.NewRecorder()
+	n.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(w, r)
+	if lang, ok := n.Negotiate(r); !ok || lang != "en" {
+		t.Errorf("Negotiate() = %v, %v, want en", lang, ok)
+	}
+	if vary := w.Header().Values("Vary"); !reflect.DeepEqual(vary, []string{"Accept-Language"}) {
+		t.Errorf("Vary = %v, want Accept-Language only", vary)
+	}
+}
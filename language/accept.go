@@ -0,0 +1,63 @@
+package language
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AcceptLanguage is a language range of the Accept-Language header with its quality value
+type AcceptLanguage struct {
+	Tag string
+	Q   float64
+}
+
+/**
+* ParseAcceptLanguage parses an Accept-Language header, e.g. "fr-CH, fr;q=0.9, en;q=0.8, *;q=0.5",
+* ranges are sorted by quality, ranges with q=0 are dropped,
+* malformed ranges are skipped, the valid ones are returned along with an error listing the skipped ones
+**/
+func ParseAcceptLanguage(header string) ([]AcceptLanguage, error) {
+	var (
+		res     []AcceptLanguage
+		invalid []string
+	)
+parts:
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || strings.ContainsAny(tag, " \t") {
+			invalid = append(invalid, part)
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") && !strings.HasPrefix(param, "Q=") {
+				continue
+			}
+			v, err := strconv.ParseFloat(param[2:], 64)
+			if err != nil || v < 0 || v > 1 {
+				invalid = append(invalid, part)
+				continue parts
+			}
+			q = v
+		}
+		if q == 0 {
+			continue
+		}
+		res = append(res, AcceptLanguage{Tag: tag, Q: q})
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Q > res[j].Q
+	})
+	if len(invalid) > 0 {
+		return res, fmt.Errorf("Invalid accept language: %v", strings.Join(invalid, ", "))
+	}
+	return res, nil
+}
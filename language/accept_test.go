@@ -0,0 +1,32 @@
+package language
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    []AcceptLanguage
+		invalid bool
+	}{
+		{"", nil, false},
+		{"fr-CH, fr;q=0.9, en;q=0.8, *;q=0.5", []AcceptLanguage{{"fr-CH", 1}, {"fr", 0.9}, {"en", 0.8}, {"*", 0.5}}, false},
+		{"en;q=0.5, ko", []AcceptLanguage{{"ko", 1}, {"en", 0.5}}, false},
+		{"ja;Q=0.7, de;q=0", []AcceptLanguage{{"ja", 0.7}}, false},
+		// malformed ranges are skipped, the others are kept
+		{"en;q=abc, ko;q=0.8", []AcceptLanguage{{"ko", 0.8}}, true},
+		{"en;q=2, ;q=0.5, e n, ja", []AcceptLanguage{{"ja", 1}}, true},
+		{"zh-Hant;level=1;q=0.4", []AcceptLanguage{{"zh-Hant", 0.4}}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseAcceptLanguage(tt.header)
+		if (err != nil) != tt.invalid {
+			t.Errorf("ParseAcceptLanguage(%q) error = %v, want invalid: %v", tt.header, err, tt.invalid)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseAcceptLanguage(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -1,11 +1,11 @@
 package language
 
-import "strings"
+import "sync"
 
 type I18nLang uint16
 
 const (
-	Afrikaans = iota + 1
+	Afrikaans I18nLang = iota + 1
 	Amharic
 	Arabic
 	ModernStandardArabic
@@ -86,60 +86,191 @@ const (
 	Zulu
 )
 
+var langTags = map[I18nLang]string{
+	Afrikaans:            "af",
+	Amharic:              "am",
+	Arabic:               "ar",
+	ModernStandardArabic: "ar-001",
+	Azerbaijani:          "az",
+	Bulgarian:            "bg",
+	Bengali:              "bn",
+	Catalan:              "ca",
+	Czech:                "cs",
+	Danish:               "da",
+	German:               "de",
+	Greek:                "el",
+	English:              "en",
+	AmericanEnglish:      "en-US",
+	BritishEnglish:       "en-GB",
+	Spanish:              "es",
+	EuropeanSpanish:      "es-ES",
+	LatinAmericanSpanish: "es-419",
+	Estonian:             "et",
+	Persian:              "fa",
+	Finnish:              "fi",
+	Filipino:             "fil",
+	French:               "fr",
+	CanadianFrench:       "fr-CA",
+	Gujarati:             "gu",
+	Hebrew:               "he",
+	Hindi:                "hi",
+	Croatian:             "hr",
+	Hungarian:            "hu",
+	Armenian:             "hy",
+	Indonesian:           "id",
+	Icelandic:            "is",
+	Italian:              "it",
+	Japanese:             "ja",
+	Georgian:             "ka",
+	Kazakh:               "kk",
+	Khmer:                "km",
+	Kannada:              "kn",
+	Korean:               "ko",
+	Kirghiz:              "ky",
+	Lao:                  "lo",
+	Lithuanian:           "lt",
+	Latvian:              "lv",
+	Macedonian:           "mk",
+	Malayalam:            "ml",
+	Mongolian:            "mn",
+	Marathi:              "mr",
+	Malay:                "ms",
+	Burmese:              "my",
+	Nepali:               "ne",
+	Dutch:                "nl",
+	Norwegian:            "no",
+	Punjabi:              "pa",
+	Polish:               "pl",
+	Portuguese:           "pt",
+	BrazilianPortuguese:  "pt-BR",
+	EuropeanPortuguese:   "pt-PT",
+	Romanian:             "ro",
+	Russian:              "ru",
+	Sinhala:              "si",
+	Slovak:               "sk",
+	Slovenian:            "sl",
+	Albanian:             "sq",
+	Serbian:              "sr",
+	SerbianLatin:         "sr-Latn",
+	Swedish:              "sv",
+	Swahili:              "sw",
+	Tamil:                "ta",
+	Telugu:               "te",
+	Thai:                 "th",
+	Turkish:              "tr",
+	Ukrainian:            "uk",
+	Urdu:                 "ur",
+	Uzbek:                "uz",
+	Vietnamese:           "vi",
+	Chinese:              "zh",
+	SimplifiedChinese:    "zh-Hans",
+	TraditionalChinese:   "zh-Hant",
+	Zulu:                 "zu",
+}
+
+// registryLock guards the tables below, they can grow with Register
+var registryLock sync.RWMutex
+
+// validLangMap is keyed by the canonical tag, validLang is in declaration order
+var validLangMap = make(map[string]I18nLang)
+
+var validLang []I18nLang
+
+func init() {
+	for lang := Afrikaans; lang <= Zulu; lang++ {
+		validLangMap[langTags[lang]] = lang
+		validLang = append(validLang, lang)
+	}
+}
+
+// Shortcut returns the canonical BCP 47 tag of the language
 func (lang I18nLang) Shortcut() string {
-	switch lang {
-	case English:
-		return "en"
-	case Chinese:
-		return "zh"
-	case Korean:
-		return "ko"
-	case Russian:
-		return "ru"
-	case Japanese:
-		return "ja"
-	default:
-		return "unsupported language"
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	if tag, ok := langTags[lang]; ok {
+		return tag
 	}
+	return "unsupported language"
+}
+
+func (lang I18nLang) String() string {
+	return lang.Shortcut()
 }
 
-var validLangMap = map[string]I18nLang{
-	"en": English,
-	"zh": Chinese,
-	"ko": Korean,
-	"ru": Russian,
-	"ja": Japanese,
+func (lang I18nLang) Tag() Tag {
+	tag, _ := Parse(lang.Shortcut())
+	return tag
 }
 
-var validLang = []I18nLang{
-	English,
-	Chinese,
-	Korean,
-	Russian,
-	Japanese,
+// FromTag returns the declared or registered language of the tag, 0 if there is none
+func FromTag(tag Tag) I18nLang {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	return validLangMap[tag.String()]
 }
 
 func LangMap() map[string]I18nLang {
-	return validLangMap
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	res := make(map[string]I18nLang, len(validLangMap))
+	for tag, lang := range validLangMap {
+		res[tag] = lang
+	}
+	return res
 }
 
+/**
+* GetLang accepts any spelling of a tag, e.g. "zh_hant" or "PT-br",
+* a tag without a language of its own resolves through its likely subtags, e.g. zh_TW is zh-Hant and sr-Latn-RS is sr-Latn
+**/
 func GetLang(shortcut string) I18nLang {
-	shortcut = strings.ToLower(shortcut)
-	if lang, ok := validLangMap[shortcut]; !ok {
+	tag, err := Parse(shortcut)
+	if err != nil {
 		return 0
-	} else {
+	}
+	if lang := FromTag(tag); lang != 0 {
 		return lang
 	}
+	return resolve(tag)
+}
+
+/**
+* resolve tries language-script-region, language-script and language-region of the maximized tag,
+* then the bare language if the script is its likely one, variants and extensions are dropped
+**/
+func resolve(tag Tag) I18nLang {
+	max := maximize(Tag{Language: tag.Language, Script: tag.Script, Region: tag.Region})
+	candidates := []Tag{
+		{Language: max.Language, Script: max.Script, Region: max.Region},
+		{Language: max.Language, Script: max.Script},
+		{Language: max.Language, Region: max.Region},
+	}
+	if max.Script == likelyScripts[max.Language] {
+		candidates = append(candidates, Tag{Language: max.Language})
+	}
+	for _, candidate := range candidates {
+		if lang := FromTag(candidate); lang != 0 {
+			return lang
+		}
+	}
+	return 0
 }
 
+/**
+* Langs returns every declared and registered language, not only the enabled ones,
+* it used to return the five languages of DefaultLangs only
+**/
 func Langs() []I18nLang {
-	return validLang
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	return append([]I18nLang(nil), validLang...)
+}
+
+// DefaultLangs returns the languages supported before the full tag table, en, zh, ko, ru and ja
+func DefaultLangs() []I18nLang {
+	return []I18nLang{English, Chinese, Korean, Russian, Japanese}
 }
 
 func IsSupported(shortcut string) bool {
-	shortcut = strings.ToLower(shortcut)
-	if _, ok := validLangMap[shortcut]; ok {
-		return true
-	}
-	return false
+	return GetLang(shortcut) != 0
 }
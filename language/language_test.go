@@ -0,0 +1,37 @@
+package language
+
+import "testing"
+
+func TestGetLang(t *testing.T) {
+	tests := []struct {
+		in   string
+		want I18nLang
+	}{
+		{"en", English},
+		{"en_us", AmericanEnglish},
+		{"PT-br", BrazilianPortuguese},
+		{"zh-hant", TraditionalChinese},
+		// tags without a language of their own resolve through their likely subtags
+		{"zh_TW", TraditionalChinese},
+		{"zh-Hant-TW", TraditionalChinese},
+		{"zh-CN", SimplifiedChinese},
+		{"sr-Latn-RS", SerbianLatin},
+		{"ko-KR", Korean},
+		{"ja-JP-u-ca-japanese", Japanese},
+		{"xx", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := GetLang(tt.in); got != tt.want {
+			t.Errorf("GetLang(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestShortcutIsCanonical(t *testing.T) {
+	for _, lang := range Langs() {
+		if got := GetLang(lang.Shortcut()); got != lang {
+			t.Errorf("GetLang(%q) = %v, want the same language", lang.Shortcut(), got)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package language
+
+import "strings"
+
+// Confidence tells how well a supported language matches a preferred one
+type Confidence int
+
+const (
+	No Confidence = iota
+	Low
+	High
+	Exact
+)
+
+func (c Confidence) String() string {
+	switch c {
+	case Exact:
+		return "Exact"
+	case High:
+		return "High"
+	case Low:
+		return "Low"
+	default:
+		return "No"
+	}
+}
+
+// equivalent languages, the confidence is the best possible match between them
+var languageEquivalents = map[[2]string]Confidence{
+	{"nb", "no"}:  High,
+	{"nn", "no"}:  Low,
+	{"nn", "nb"}:  Low,
+	{"tl", "fil"}: High,
+	{"cmn", "zh"}: High,
+	{"yue", "zh"}: Low,
+	{"zsm", "ms"}: High,
+	{"arb", "ar"}: High,
+	{"swh", "sw"}: High,
+}
+
+/**
+* regionGroup returns the cluster of the region within the language,
+* e.g. en-AU and en-GB both fall back to en-001 in CLDR
+**/
+func regionGroup(lang string, region string) string {
+	switch lang {
+	case "en":
+		switch region {
+		case "", "US", "AS", "GU", "MH", "MP", "PR", "UM", "VI":
+			return "US"
+		}
+		return "001"
+	case "es":
+		switch region {
+		case "", "ES", "EA", "IC", "GQ", "PH":
+			return "ES"
+		}
+		return "419"
+	case "pt":
+		switch region {
+		case "", "BR":
+			return "BR"
+		}
+		return "PT"
+	}
+	return region
+}
+
+func languageEquivalence(a string, b string) Confidence {
+	if a == b {
+		return Exact
+	}
+	if c, ok := languageEquivalents[[2]string{a, b}]; ok {
+		return c
+	}
+	if c, ok := languageEquivalents[[2]string{b, a}]; ok {
+		return c
+	}
+	return No
+}
+
+/**
+* distance between a preferred and a supported tag, the lower the better,
+* it returns No if the tags do not match at all
+**/
+func distance(desired Tag, supported Tag) (int, Confidence) {
+	if desired.String() == supported.String() {
+		return 0, Exact
+	}
+	conf := languageEquivalence(desired.Language, supported.Language)
+	if conf == No {
+		return 0, No
+	}
+	d := 0
+	if conf < Exact {
+		d += 20
+	} else {
+		conf = High
+	}
+	maxDesired, maxSupported := maximize(desired), maximize(supported)
+	if maxDesired.Script != maxSupported.Script {
+		d += 40
+		conf = Low
+	}
+	switch {
+	case maxDesired.Region == maxSupported.Region:
+	case regionGroup(maxDesired.Language, maxDesired.Region) == regionGroup(maxSupported.Language, maxSupported.Region):
+		d += 4
+	case supported.Region == "":
+		d += 6
+	default:
+		d += 8
+	}
+	if desired.Variant != supported.Variant {
+		d++
+	}
+	return d, conf
+}
+
+// Matcher picks the best supported language for a list of preferred tags
+type Matcher struct {
+	supported []I18nLang
+	tags      []Tag
+}
+
+func NewMatcher(supported []I18nLang) *Matcher {
+	m := &Matcher{}
+	for _, lang := range supported {
+		tag := lang.Tag()
+		if tag.IsZero() {
+			continue
+		}
+		m.supported = append(m.supported, lang)
+		m.tags = append(m.tags, tag)
+	}
+	return m
+}
+
+/**
+* Match returns the best supported language, preferred tags are tried in order of preference,
+* a later preferred tag only wins if the earlier ones have a low or no match,
+* the first supported language is returned with No confidence if nothing matches
+**/
+func (m *Matcher) Match(preferred ...Tag) (I18nLang, Confidence) {
+	var (
+		best     I18nLang
+		bestConf = No
+	)
+	for _, desired := range preferred {
+		lang, conf := m.matchOne(desired)
+		if conf >= High {
+			return lang, conf
+		}
+		if conf > bestConf {
+			best, bestConf = lang, conf
+		}
+	}
+	if bestConf == No && len(m.supported) > 0 {
+		return m.supported[0], No
+	}
+	return best, bestConf
+}
+
+// MatchStrings parses the preferred tags, invalid ones are skipped
+func (m *Matcher) MatchStrings(preferred ...string) (I18nLang, Confidence) {
+	var tags []Tag
+	for _, s := range preferred {
+		if tag, err := Parse(strings.TrimSpace(s)); err == nil {
+			tags = append(tags, tag)
+		}
+	}
+	return m.Match(tags...)
+}
+
+func (m *Matcher) matchOne(desired Tag) (I18nLang, Confidence) {
+	var (
+		best     I18nLang
+		bestConf = No
+		bestDist int
+	)
+	// extensions do not take part in the match
+	desired.Extension = ""
+	for i, supported := range m.tags {
+		d, conf := distance(desired, supported)
+		if conf == No {
+			continue
+		}
+		if bestConf == No || conf > bestConf || conf == bestConf && d < bestDist {
+			best, bestConf, bestDist = m.supported[i], conf, d
+		}
+	}
+	return best, bestConf
+}
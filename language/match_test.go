@@ -0,0 +1,64 @@
+package language
+
+import "testing"
+
+func TestMaximize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"en", "en-Latn-US"},
+		{"zh", "zh-Hans-CN"},
+		{"zh-TW", "zh-Hant-TW"},
+		{"zh-Hant", "zh-Hant-TW"},
+		{"sr-ME", "sr-Latn-ME"},
+		{"pt", "pt-Latn-BR"},
+		{"ja", "ja-Jpan"},
+		{"en-GB", "en-Latn-GB"},
+	}
+	for _, tt := range tests {
+		if got := maximize(MustParse(tt.in)).String(); got != tt.want {
+			t.Errorf("maximize(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	m := NewMatcher([]I18nLang{English, BritishEnglish, TraditionalChinese, SimplifiedChinese, Norwegian, BrazilianPortuguese, Japanese})
+	tests := []struct {
+		preferred []string
+		want      I18nLang
+		conf      Confidence
+	}{
+		{[]string{"ja"}, Japanese, Exact},
+		{[]string{"en-GB"}, BritishEnglish, Exact},
+		// en-AU is in the en-001 cluster of en-GB
+		{[]string{"en-AU"}, BritishEnglish, High},
+		{[]string{"en-US"}, English, High},
+		{[]string{"zh-TW"}, TraditionalChinese, High},
+		{[]string{"zh-HK"}, TraditionalChinese, High},
+		{[]string{"zh"}, SimplifiedChinese, High},
+		{[]string{"nb"}, Norwegian, High},
+		{[]string{"pt-PT"}, BrazilianPortuguese, High},
+		// a different script is a low match
+		{[]string{"sr-Latn"}, English, No},
+		// an earlier preferred tag with a high match wins
+		{[]string{"fr", "ja", "en"}, Japanese, Exact},
+		{[]string{"x-invalid!", "en-CA"}, BritishEnglish, High},
+		{[]string{"fr"}, English, No},
+		{nil, English, No},
+	}
+	for _, tt := range tests {
+		lang, conf := m.MatchStrings(tt.preferred...)
+		if lang != tt.want || conf != tt.conf {
+			t.Errorf("MatchStrings(%v) = %v, %v, want %v, %v", tt.preferred, lang, conf, tt.want, tt.conf)
+		}
+	}
+}
+
+func TestMatcherLowConfidence(t *testing.T) {
+	m := NewMatcher([]I18nLang{English, TraditionalChinese})
+	if lang, conf := m.MatchStrings("zh-Hans"); lang != TraditionalChinese || conf != Low {
+		t.Errorf("MatchStrings(zh-Hans) = %v, %v, want zh-Hant, Low", lang, conf)
+	}
+}
@@ -0,0 +1,524 @@
+package language
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PluralCategory is one of the CLDR plural categories
+type PluralCategory string
+
+const (
+	PluralZero  PluralCategory = "zero"
+	PluralOne   PluralCategory = "one"
+	PluralTwo   PluralCategory = "two"
+	PluralFew   PluralCategory = "few"
+	PluralMany  PluralCategory = "many"
+	PluralOther PluralCategory = "other"
+)
+
+var pluralCategories = []PluralCategory{
+	PluralZero,
+	PluralOne,
+	PluralTwo,
+	PluralFew,
+	PluralMany,
+	PluralOther,
+}
+
+func IsPluralCategory(category string) bool {
+	for _, c := range pluralCategories {
+		if string(c) == category {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+* Operands are the CLDR plural operands of a number
+* see https://unicode.org/reports/tr35/tr35-numbers.html#Operands
+**/
+type Operands struct {
+	N float64 // absolute value
+	I int64   // integer digits
+	V int     // number of visible fraction digits, with trailing zeros
+	W int     // number of visible fraction digits, without trailing zeros
+	F int64   // visible fraction digits, with trailing zeros
+	T int64   // visible fraction digits, without trailing zeros
+}
+
+// NewOperands accepts any integer or float type, or a decimal string such as "1.50"
+func NewOperands(count interface{}) (*Operands, error) {
+	switch v := count.(type) {
+	case int:
+		return intOperands(int64(v)), nil
+	case int8:
+		return intOperands(int64(v)), nil
+	case int16:
+		return intOperands(int64(v)), nil
+	case int32:
+		return intOperands(int64(v)), nil
+	case int64:
+		return intOperands(v), nil
+	case uint:
+		return newDecimalOperands(strconv.FormatUint(uint64(v), 10))
+	case uint8:
+		return intOperands(int64(v)), nil
+	case uint16:
+		return intOperands(int64(v)), nil
+	case uint32:
+		return intOperands(int64(v)), nil
+	case uint64:
+		return newDecimalOperands(strconv.FormatUint(v, 10))
+	case float32:
+		return newDecimalOperands(strconv.FormatFloat(float64(v), 'f', -1, 32))
+	case float64:
+		return newDecimalOperands(strconv.FormatFloat(v, 'f', -1, 64))
+	case string:
+		return newDecimalOperands(v)
+	default:
+		return nil, fmt.Errorf("Invalid plural count type: %T", count)
+	}
+}
+
+func intOperands(i int64) *Operands {
+	if i < 0 {
+		i = -i
+	}
+	return &Operands{N: float64(i), I: i}
+}
+
+func newDecimalOperands(s string) (*Operands, error) {
+	s = strings.TrimLeft(strings.TrimSpace(s), "+-")
+	parts := strings.SplitN(s, ".", 2)
+	if parts[0] == "" || !isDigits(parts[0]) {
+		return nil, fmt.Errorf("Invalid plural count: %v", s)
+	}
+	ops := &Operands{}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid plural count: %v", s)
+	}
+	ops.N = n
+	// integer digits beyond int64 only matter for their last digits
+	intDigits := parts[0]
+	if len(intDigits) > 18 {
+		intDigits = intDigits[len(intDigits)-18:]
+	}
+	ops.I, _ = strconv.ParseInt(intDigits, 10, 64)
+	if len(parts) == 2 {
+		fraction := parts[1]
+		if !isDigits(fraction) || len(fraction) > 18 {
+			return nil, fmt.Errorf("Invalid plural count: %v", s)
+		}
+		ops.V = len(fraction)
+		if ops.V > 0 {
+			ops.F, _ = strconv.ParseInt(fraction, 10, 64)
+		}
+		trimmed := strings.TrimRight(fraction, "0")
+		ops.W = len(trimmed)
+		if ops.W > 0 {
+			ops.T, _ = strconv.ParseInt(trimmed, 10, 64)
+		}
+	}
+	return ops, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// nIn reports whether n is an integer within [from, to]
+func (ops *Operands) nIn(from, to int64) bool {
+	return ops.N == math.Trunc(ops.N) && ops.N >= float64(from) && ops.N <= float64(to)
+}
+
+// nModIn reports whether n % mod is an integer within [from, to]
+func (ops *Operands) nModIn(mod, from, to int64) bool {
+	m := math.Mod(ops.N, float64(mod))
+	return m == math.Trunc(m) && m >= float64(from) && m <= float64(to)
+}
+
+func inRange(v, from, to int64) bool {
+	return v >= from && v <= to
+}
+
+// PluralFunc selects the plural category of the operands
+type PluralFunc func(ops *Operands) PluralCategory
+
+type pluralRule struct {
+	categories []PluralCategory
+	selector   PluralFunc
+}
+
+var (
+	pluralRuleOther = &pluralRule{
+		categories: []PluralCategory{PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			return PluralOther
+		},
+	}
+	// one: n = 1
+	pluralRuleOneN = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			if ops.nIn(1, 1) {
+				return PluralOne
+			}
+			return PluralOther
+		},
+	}
+	// one: i = 1 and v = 0
+	pluralRuleOneIV = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			if ops.I == 1 && ops.V == 0 {
+				return PluralOne
+			}
+			return PluralOther
+		},
+	}
+	// one: i = 0 or n = 1
+	pluralRuleOneI0N1 = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			if ops.I == 0 || ops.nIn(1, 1) {
+				return PluralOne
+			}
+			return PluralOther
+		},
+	}
+	// one: i = 0,1
+	pluralRuleOneI01 = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			if ops.I == 0 || ops.I == 1 {
+				return PluralOne
+			}
+			return PluralOther
+		},
+	}
+	// one: n = 0..1
+	pluralRuleOneN01 = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			if ops.nIn(0, 1) {
+				return PluralOne
+			}
+			return PluralOther
+		},
+	}
+	pluralRuleDanish = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			if ops.nIn(1, 1) || (ops.T != 0 && (ops.I == 0 || ops.I == 1)) {
+				return PluralOne
+			}
+			return PluralOther
+		},
+	}
+	pluralRuleFilipino = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			if ops.V == 0 && inRange(ops.I, 1, 3) ||
+				ops.V == 0 && ops.I%10 != 4 && ops.I%10 != 6 && ops.I%10 != 9 ||
+				ops.V != 0 && ops.F%10 != 4 && ops.F%10 != 6 && ops.F%10 != 9 {
+				return PluralOne
+			}
+			return PluralOther
+		},
+	}
+	pluralRuleIcelandic = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			if ops.T == 0 && ops.I%10 == 1 && ops.I%100 != 11 || ops.T%10 == 1 && ops.T%100 != 11 {
+				return PluralOne
+			}
+			return PluralOther
+		},
+	}
+	pluralRuleMacedonian = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			if ops.V == 0 && ops.I%10 == 1 && ops.I%100 != 11 || ops.F%10 == 1 && ops.F%100 != 11 {
+				return PluralOne
+			}
+			return PluralOther
+		},
+	}
+	pluralRuleSinhala = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			if ops.nIn(0, 1) || ops.I == 0 && ops.F == 1 {
+				return PluralOne
+			}
+			return PluralOther
+		},
+	}
+	pluralRuleHebrew = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralTwo, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			switch {
+			case ops.I == 1 && ops.V == 0 || ops.I == 0 && ops.V != 0:
+				return PluralOne
+			case ops.I == 2 && ops.V == 0:
+				return PluralTwo
+			default:
+				return PluralOther
+			}
+		},
+	}
+	pluralRuleRomanian = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralFew, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			switch {
+			case ops.I == 1 && ops.V == 0:
+				return PluralOne
+			case ops.V != 0 || ops.nIn(0, 0) || !ops.nIn(1, 1) && ops.nModIn(100, 1, 19):
+				return PluralFew
+			default:
+				return PluralOther
+			}
+		},
+	}
+	// used by Croatian and Serbian
+	pluralRuleCroatian = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralFew, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			switch {
+			case ops.V == 0 && ops.I%10 == 1 && ops.I%100 != 11 || ops.F%10 == 1 && ops.F%100 != 11:
+				return PluralOne
+			case ops.V == 0 && inRange(ops.I%10, 2, 4) && !inRange(ops.I%100, 12, 14) ||
+				inRange(ops.F%10, 2, 4) && !inRange(ops.F%100, 12, 14):
+				return PluralFew
+			default:
+				return PluralOther
+			}
+		},
+	}
+	// used by Russian and Ukrainian
+	pluralRuleRussian = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralFew, PluralMany, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			switch {
+			case ops.V != 0:
+				return PluralOther
+			case ops.I%10 == 1 && ops.I%100 != 11:
+				return PluralOne
+			case inRange(ops.I%10, 2, 4) && !inRange(ops.I%100, 12, 14):
+				return PluralFew
+			default:
+				return PluralMany
+			}
+		},
+	}
+	// used by Czech and Slovak
+	pluralRuleCzech = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralFew, PluralMany, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			switch {
+			case ops.I == 1 && ops.V == 0:
+				return PluralOne
+			case inRange(ops.I, 2, 4) && ops.V == 0:
+				return PluralFew
+			case ops.V != 0:
+				return PluralMany
+			default:
+				return PluralOther
+			}
+		},
+	}
+	pluralRulePolish = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralFew, PluralMany, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			switch {
+			case ops.V != 0:
+				return PluralOther
+			case ops.I == 1:
+				return PluralOne
+			case inRange(ops.I%10, 2, 4) && !inRange(ops.I%100, 12, 14):
+				return PluralFew
+			default:
+				return PluralMany
+			}
+		},
+	}
+	pluralRuleLithuanian = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralFew, PluralMany, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			switch {
+			case ops.F != 0:
+				return PluralMany
+			case ops.nModIn(10, 1, 1) && !ops.nModIn(100, 11, 19):
+				return PluralOne
+			case ops.nModIn(10, 2, 9) && !ops.nModIn(100, 11, 19):
+				return PluralFew
+			default:
+				return PluralOther
+			}
+		},
+	}
+	pluralRuleLatvian = &pluralRule{
+		categories: []PluralCategory{PluralZero, PluralOne, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			switch {
+			case ops.nModIn(10, 0, 0) || ops.nModIn(100, 11, 19) || ops.V == 2 && inRange(ops.F%100, 11, 19):
+				return PluralZero
+			case ops.nModIn(10, 1, 1) && !ops.nModIn(100, 11, 11) ||
+				ops.V == 2 && ops.F%10 == 1 && ops.F%100 != 11 ||
+				ops.V != 2 && ops.F%10 == 1:
+				return PluralOne
+			default:
+				return PluralOther
+			}
+		},
+	}
+	pluralRuleSlovenian = &pluralRule{
+		categories: []PluralCategory{PluralOne, PluralTwo, PluralFew, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			switch {
+			case ops.V == 0 && ops.I%100 == 1:
+				return PluralOne
+			case ops.V == 0 && ops.I%100 == 2:
+				return PluralTwo
+			case ops.V == 0 && inRange(ops.I%100, 3, 4) || ops.V != 0:
+				return PluralFew
+			default:
+				return PluralOther
+			}
+		},
+	}
+	pluralRuleArabic = &pluralRule{
+		categories: []PluralCategory{PluralZero, PluralOne, PluralTwo, PluralFew, PluralMany, PluralOther},
+		selector: func(ops *Operands) PluralCategory {
+			switch {
+			case ops.nIn(0, 0):
+				return PluralZero
+			case ops.nIn(1, 1):
+				return PluralOne
+			case ops.nIn(2, 2):
+				return PluralTwo
+			case ops.nModIn(100, 3, 10):
+				return PluralFew
+			case ops.nModIn(100, 11, 99):
+				return PluralMany
+			default:
+				return PluralOther
+			}
+		},
+	}
+)
+
+var pluralRules = map[I18nLang]*pluralRule{
+	Afrikaans:            pluralRuleOneN,
+	Amharic:              pluralRuleOneI0N1,
+	Arabic:               pluralRuleArabic,
+	ModernStandardArabic: pluralRuleArabic,
+	Azerbaijani:          pluralRuleOneN,
+	Bulgarian:            pluralRuleOneN,
+	Bengali:              pluralRuleOneI0N1,
+	Catalan:              pluralRuleOneIV,
+	Czech:                pluralRuleCzech,
+	Danish:               pluralRuleDanish,
+	German:               pluralRuleOneIV,
+	Greek:                pluralRuleOneN,
+	English:              pluralRuleOneIV,
+	AmericanEnglish:      pluralRuleOneIV,
+	BritishEnglish:       pluralRuleOneIV,
+	Spanish:              pluralRuleOneN,
+	EuropeanSpanish:      pluralRuleOneN,
+	LatinAmericanSpanish: pluralRuleOneN,
+	Estonian:             pluralRuleOneIV,
+	Persian:              pluralRuleOneI0N1,
+	Finnish:              pluralRuleOneIV,
+	Filipino:             pluralRuleFilipino,
+	French:               pluralRuleOneI01,
+	CanadianFrench:       pluralRuleOneI01,
+	Gujarati:             pluralRuleOneI0N1,
+	Hebrew:               pluralRuleHebrew,
+	Hindi:                pluralRuleOneI0N1,
+	Croatian:             pluralRuleCroatian,
+	Hungarian:            pluralRuleOneN,
+	Armenian:             pluralRuleOneI01,
+	Indonesian:           pluralRuleOther,
+	Icelandic:            pluralRuleIcelandic,
+	Italian:              pluralRuleOneIV,
+	Japanese:             pluralRuleOther,
+	Georgian:             pluralRuleOneN,
+	Kazakh:               pluralRuleOneN,
+	Khmer:                pluralRuleOther,
+	Kannada:              pluralRuleOneI0N1,
+	Korean:               pluralRuleOther,
+	Kirghiz:              pluralRuleOneN,
+	Lao:                  pluralRuleOther,
+	Lithuanian:           pluralRuleLithuanian,
+	Latvian:              pluralRuleLatvian,
+	Macedonian:           pluralRuleMacedonian,
+	Malayalam:            pluralRuleOneN,
+	Mongolian:            pluralRuleOneN,
+	Marathi:              pluralRuleOneN,
+	Malay:                pluralRuleOther,
+	Burmese:              pluralRuleOther,
+	Nepali:               pluralRuleOneN,
+	Dutch:                pluralRuleOneIV,
+	Norwegian:            pluralRuleOneN,
+	Punjabi:              pluralRuleOneN01,
+	Polish:               pluralRulePolish,
+	Portuguese:           pluralRuleOneI01,
+	BrazilianPortuguese:  pluralRuleOneI01,
+	EuropeanPortuguese:   pluralRuleOneIV,
+	Romanian:             pluralRuleRomanian,
+	Russian:              pluralRuleRussian,
+	Sinhala:              pluralRuleSinhala,
+	Slovak:               pluralRuleCzech,
+	Slovenian:            pluralRuleSlovenian,
+	Albanian:             pluralRuleOneN,
+	Serbian:              pluralRuleCroatian,
+	SerbianLatin:         pluralRuleCroatian,
+	Swedish:              pluralRuleOneIV,
+	Swahili:              pluralRuleOneIV,
+	Tamil:                pluralRuleOneN,
+	Telugu:               pluralRuleOneN,
+	Thai:                 pluralRuleOther,
+	Turkish:              pluralRuleOneN,
+	Ukrainian:            pluralRuleRussian,
+	Urdu:                 pluralRuleOneIV,
+	Uzbek:                pluralRuleOneN,
+	Vietnamese:           pluralRuleOther,
+	Chinese:              pluralRuleOther,
+	SimplifiedChinese:    pluralRuleOther,
+	TraditionalChinese:   pluralRuleOther,
+	Zulu:                 pluralRuleOneI0N1,
+}
+
+func (lang I18nLang) pluralRule() *pluralRule {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	if rule, ok := pluralRules[lang]; ok {
+		return rule
+	}
+	return pluralRuleOther
+}
+
+// PluralCategories returns the categories used by the language, in CLDR order
+func (lang I18nLang) PluralCategories() []PluralCategory {
+	categories := lang.pluralRule().categories
+	return append([]PluralCategory(nil), categories...)
+}
+
+// PluralCategory selects the CLDR cardinal plural category of count
+func (lang I18nLang) PluralCategory(count interface{}) (PluralCategory, error) {
+	ops, err := NewOperands(count)
+	if err != nil {
+		return PluralOther, err
+	}
+	return lang.pluralRule().selector(ops), nil
+}
@@ -0,0 +1,117 @@
+package language
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPluralCategory(t *testing.T) {
+	tests := []struct {
+		lang  I18nLang
+		count interface{}
+		want  PluralCategory
+	}{
+		{English, 1, PluralOne},
+		{English, 0, PluralOther},
+		{English, 2, PluralOther},
+		{English, "1.0", PluralOther},
+		{English, 1.5, PluralOther},
+		{French, 0, PluralOne},
+		{French, 1.5, PluralOne},
+		{French, 2, PluralOther},
+		{Russian, 1, PluralOne},
+		{Russian, 21, PluralOne},
+		{Russian, 2, PluralFew},
+		{Russian, 22, PluralFew},
+		{Russian, 0, PluralMany},
+		{Russian, 11, PluralMany},
+		{Russian, 12, PluralMany},
+		{Russian, "1.5", PluralOther},
+		{Arabic, 0, PluralZero},
+		{Arabic, 1, PluralOne},
+		{Arabic, 2, PluralTwo},
+		{Arabic, 3, PluralFew},
+		{Arabic, 103, PluralFew},
+		{Arabic, 11, PluralMany},
+		{Arabic, 99, PluralMany},
+		{Arabic, 100, PluralOther},
+		{Arabic, 102, PluralOther},
+		{Polish, 1, PluralOne},
+		{Polish, 2, PluralFew},
+		{Polish, 22, PluralFew},
+		{Polish, 5, PluralMany},
+		{Polish, 12, PluralMany},
+		{Polish, 1.5, PluralOther},
+		{Czech, 1, PluralOne},
+		{Czech, 4, PluralFew},
+		{Czech, 5, PluralOther},
+		{Czech, 1.5, PluralMany},
+		{Latvian, 0, PluralZero},
+		{Latvian, 11, PluralZero},
+		{Latvian, 1, PluralOne},
+		{Latvian, 21, PluralOne},
+		{Latvian, "0.1", PluralOne},
+		{Latvian, 2, PluralOther},
+		{Lithuanian, 1, PluralOne},
+		{Lithuanian, 2, PluralFew},
+		{Lithuanian, 11, PluralOther},
+		{Lithuanian, 0.5, PluralMany},
+		{Japanese, 1, PluralOther},
+		{English, int64(-1), PluralOne},
+		{English, uint8(1), PluralOne},
+	}
+	for _, tt := range tests {
+		got, err := tt.lang.PluralCategory(tt.count)
+		if err != nil {
+			t.Errorf("%v.PluralCategory(%v) error: %v", tt.lang.Shortcut(), tt.count, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%v.PluralCategory(%v) = %v, want %v", tt.lang.Shortcut(), tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestPluralCategories(t *testing.T) {
+	tests := []struct {
+		lang I18nLang
+		want []PluralCategory
+	}{
+		{English, []PluralCategory{PluralOne, PluralOther}},
+		{Japanese, []PluralCategory{PluralOther}},
+		{Russian, []PluralCategory{PluralOne, PluralFew, PluralMany, PluralOther}},
+		{Arabic, []PluralCategory{PluralZero, PluralOne, PluralTwo, PluralFew, PluralMany, PluralOther}},
+	}
+	for _, tt := range tests {
+		if got := tt.lang.PluralCategories(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v.PluralCategories() = %v, want %v", tt.lang.Shortcut(), got, tt.want)
+		}
+	}
+}
+
+func TestNewOperands(t *testing.T) {
+	tests := []struct {
+		count interface{}
+		want  Operands
+	}{
+		{3, Operands{N: 3, I: 3}},
+		{"1.50", Operands{N: 1.5, I: 1, V: 2, W: 1, F: 50, T: 5}},
+		{"-2.0", Operands{N: 2, I: 2, V: 1}},
+		{1.25, Operands{N: 1.25, I: 1, V: 2, W: 2, F: 25, T: 25}},
+	}
+	for _, tt := range tests {
+		got, err := NewOperands(tt.count)
+		if err != nil {
+			t.Errorf("NewOperands(%v) error: %v", tt.count, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("NewOperands(%v) = %+v, want %+v", tt.count, *got, tt.want)
+		}
+	}
+	for _, count := range []interface{}{"1.x", "", struct{}{}} {
+		if _, err := NewOperands(count); err == nil {
+			t.Errorf("NewOperands(%#v) succeeded", count)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package language
+
+import (
+	"fmt"
+	"math"
+)
+
+// Direction is the writing direction of a language
+type Direction int
+
+const (
+	LeftToRight Direction = iota
+	RightToLeft
+)
+
+func (d Direction) String() string {
+	if d == RightToLeft {
+		return "rtl"
+	}
+	return "ltr"
+}
+
+// LangOptions describes a language added with Register
+type LangOptions struct {
+	// Name is the English display name
+	Name string
+	// NativeName is the display name in the language itself
+	NativeName string
+	Direction  Direction
+	// Parent is the tag of the parent locale, e.g. "en" for "en-XA",
+	// the tag truncated by its last subtag is used if empty and registered
+	Parent string
+	// PluralCategories are the categories returned by Plural, other is always included,
+	// the plural rule of the parent is used if Plural is nil
+	PluralCategories []PluralCategory
+	Plural           PluralFunc
+}
+
+var langNames = map[I18nLang]string{
+	Afrikaans:            "Afrikaans",
+	Amharic:              "Amharic",
+	Arabic:               "Arabic",
+	ModernStandardArabic: "Modern Standard Arabic",
+	Azerbaijani:          "Azerbaijani",
+	Bulgarian:            "Bulgarian",
+	Bengali:              "Bengali",
+	Catalan:              "Catalan",
+	Czech:                "Czech",
+	Danish:               "Danish",
+	German:               "German",
+	Greek:                "Greek",
+	English:              "English",
+	AmericanEnglish:      "American English",
+	BritishEnglish:       "British English",
+	Spanish:              "Spanish",
+	EuropeanSpanish:      "European Spanish",
+	LatinAmericanSpanish: "Latin American Spanish",
+	Estonian:             "Estonian",
+	Persian:              "Persian",
+	Finnish:              "Finnish",
+	Filipino:             "Filipino",
+	French:               "French",
+	CanadianFrench:       "Canadian French",
+	Gujarati:             "Gujarati",
+	Hebrew:               "Hebrew",
+	Hindi:                "Hindi",
+	Croatian:             "Croatian",
+	Hungarian:            "Hungarian",
+	Armenian:             "Armenian",
+	Indonesian:           "Indonesian",
+	Icelandic:            "Icelandic",
+	Italian:              "Italian",
+	Japanese:             "Japanese",
+	Georgian:             "Georgian",
+	Kazakh:               "Kazakh",
+	Khmer:                "Khmer",
+	Kannada:              "Kannada",
+	Korean:               "Korean",
+	Kirghiz:              "Kirghiz",
+	Lao:                  "Lao",
+	Lithuanian:           "Lithuanian",
+	Latvian:              "Latvian",
+	Macedonian:           "Macedonian",
+	Malayalam:            "Malayalam",
+	Mongolian:            "Mongolian",
+	Marathi:              "Marathi",
+	Malay:                "Malay",
+	Burmese:              "Burmese",
+	Nepali:               "Nepali",
+	Dutch:                "Dutch",
+	Norwegian:            "Norwegian",
+	Punjabi:              "Punjabi",
+	Polish:               "Polish",
+	Portuguese:           "Portuguese",
+	BrazilianPortuguese:  "Brazilian Portuguese",
+	EuropeanPortuguese:   "European Portuguese",
+	Romanian:             "Romanian",
+	Russian:              "Russian",
+	Sinhala:              "Sinhala",
+	Slovak:               "Slovak",
+	Slovenian:            "Slovenian",
+	Albanian:             "Albanian",
+	Serbian:              "Serbian",
+	SerbianLatin:         "Serbian (Latin)",
+	Swedish:              "Swedish",
+	Swahili:              "Swahili",
+	Tamil:                "Tamil",
+	Telugu:               "Telugu",
+	Thai:                 "Thai",
+	Turkish:              "Turkish",
+	Ukrainian:            "Ukrainian",
+	Urdu:                 "Urdu",
+	Uzbek:                "Uzbek",
+	Vietnamese:           "Vietnamese",
+	Chinese:              "Chinese",
+	SimplifiedChinese:    "Simplified Chinese",
+	TraditionalChinese:   "Traditional Chinese",
+	Zulu:                 "Zulu",
+}
+
+var nativeNames = make(map[I18nLang]string)
+
+var directions = map[I18nLang]Direction{
+	Arabic:               RightToLeft,
+	ModernStandardArabic: RightToLeft,
+	Persian:              RightToLeft,
+	Hebrew:               RightToLeft,
+	Urdu:                 RightToLeft,
+}
+
+// parents follow the CLDR parent locales
+var parents = map[I18nLang]I18nLang{
+	ModernStandardArabic: Arabic,
+	AmericanEnglish:      English,
+	BritishEnglish:       English,
+	EuropeanSpanish:      Spanish,
+	LatinAmericanSpanish: Spanish,
+	CanadianFrench:       French,
+	BrazilianPortuguese:  Portuguese,
+	EuropeanPortuguese:   Portuguese,
+	SimplifiedChinese:    Chinese,
+}
+
+var nextLang = Zulu + 1
+
+/**
+* Register adds a language, e.g. a pseudo-locale or a regional dialect,
+* it is then supported by IsSupported and GetLang like the declared ones
+**/
+func Register(tag string, opts LangOptions) (I18nLang, error) {
+	t, err := Parse(tag)
+	if err != nil {
+		return 0, err
+	}
+	var parent I18nLang
+	if opts.Parent != "" {
+		if parent = GetLang(opts.Parent); parent == 0 {
+			return 0, fmt.Errorf("Unsupported parent language: %v", opts.Parent)
+		}
+	} else {
+		parent = FromTag(truncate(t))
+	}
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, ok := validLangMap[t.String()]; ok {
+		return 0, fmt.Errorf("Language already registered: %v", t)
+	}
+	if nextLang == math.MaxUint16 {
+		return 0, fmt.Errorf("Too many registered languages")
+	}
+	lang := nextLang
+	nextLang++
+	langTags[lang] = t.String()
+	validLangMap[t.String()] = lang
+	validLang = append(validLang, lang)
+	langNames[lang] = opts.Name
+	nativeNames[lang] = opts.NativeName
+	directions[lang] = opts.Direction
+	if parent != 0 {
+		parents[lang] = parent
+	}
+	switch {
+	case opts.Plural != nil:
+		categories := opts.PluralCategories
+		if !containsCategory(categories, PluralOther) {
+			categories = append(append([]PluralCategory(nil), categories...), PluralOther)
+		}
+		pluralRules[lang] = &pluralRule{categories: categories, selector: opts.Plural}
+	case parent != 0 && pluralRules[parent] != nil:
+		pluralRules[lang] = pluralRules[parent]
+	}
+	return lang, nil
+}
+
+// truncate removes the last subtag, the zero tag is returned for a single subtag
+func truncate(t Tag) Tag {
+	switch {
+	case t.Extension != "":
+		t.Extension = ""
+	case t.Variant != "":
+		t.Variant = ""
+	case t.Region != "":
+		t.Region = ""
+	case t.Script != "":
+		t.Script = ""
+	default:
+		return Tag{}
+	}
+	return t
+}
+
+func containsCategory(categories []PluralCategory, category PluralCategory) bool {
+	for _, c := range categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Name returns the English display name
+func (lang I18nLang) Name() string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	return langNames[lang]
+}
+
+// NativeName returns the display name in the language itself, the English name if unknown
+func (lang I18nLang) NativeName() string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	if name := nativeNames[lang]; name != "" {
+		return name
+	}
+	return langNames[lang]
+}
+
+func (lang I18nLang) Direction() Direction {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	return directions[lang]
+}
+
+// Parent returns the parent locale, 0 for a root language
+func (lang I18nLang) Parent() I18nLang {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	return parents[lang]
+}
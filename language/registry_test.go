@@ -0,0 +1,96 @@
+package language
+
+import "testing"
+
+func TestRegister(t *testing.T) {
+	pseudo, err := Register("en-XA", LangOptions{Name: "Pseudo English", NativeName: "[Ƥşḗḗŭḓǿǿ]"})
+	if err != nil {
+		t.Fatalf("Register(en-XA) error: %v", err)
+	}
+	if got := GetLang("en_XA"); got != pseudo {
+		t.Errorf("GetLang(en_XA) = %v, want %v", got, pseudo)
+	}
+	if !IsSupported("en-xa") {
+		t.Errorf("IsSupported(en-xa) = false")
+	}
+	if got := pseudo.Shortcut(); got != "en-XA" {
+		t.Errorf("Shortcut() = %v, want en-XA", got)
+	}
+	if got := pseudo.Parent(); got != English {
+		t.Errorf("Parent() = %v, want en", got)
+	}
+	// the plural rule of the parent is inherited
+	if got, _ := pseudo.PluralCategory(1); got != PluralOne {
+		t.Errorf("PluralCategory(1) = %v, want one", got)
+	}
+
+	bidi, err := Register("ar-XB", LangOptions{
+		Name:             "Pseudo Bidi",
+		Direction:        RightToLeft,
+		Parent:           "en",
+		PluralCategories: []PluralCategory{PluralOne},
+		Plural: func(ops *Operands) PluralCategory {
+			if ops.I == 1 && ops.V == 0 {
+				return PluralOne
+			}
+			return PluralOther
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register(ar-XB) error: %v", err)
+	}
+	if got := bidi.Parent(); got != English {
+		t.Errorf("Parent() = %v, want en", got)
+	}
+	if got := bidi.Direction(); got != RightToLeft {
+		t.Errorf("Direction() = %v, want rtl", got)
+	}
+	if got := bidi.PluralCategories(); len(got) != 2 || got[1] != PluralOther {
+		t.Errorf("PluralCategories() = %v, want [one other]", got)
+	}
+	// the English name is used without a native name
+	if got := bidi.NativeName(); got != "Pseudo Bidi" {
+		t.Errorf("NativeName() = %v, want Pseudo Bidi", got)
+	}
+
+	if _, err := Register("en-XA", LangOptions{}); err == nil {
+		t.Errorf("Register(en-XA) twice succeeded")
+	}
+	if _, err := Register("de", LangOptions{}); err == nil {
+		t.Errorf("Register(de) succeeded")
+	}
+	if _, err := Register("fr-XC", LangOptions{Parent: "xx"}); err == nil {
+		t.Errorf("Register with an unknown parent succeeded")
+	}
+	if _, err := Register("not a tag", LangOptions{}); err == nil {
+		t.Errorf("Register(not a tag) succeeded")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	tests := []struct {
+		lang      I18nLang
+		name      string
+		direction Direction
+		parent    I18nLang
+	}{
+		{English, "English", LeftToRight, 0},
+		{BritishEnglish, "British English", LeftToRight, English},
+		{Arabic, "Arabic", RightToLeft, 0},
+		{Hebrew, "Hebrew", RightToLeft, 0},
+		{BrazilianPortuguese, "Brazilian Portuguese", LeftToRight, Portuguese},
+		{SimplifiedChinese, "Simplified Chinese", LeftToRight, Chinese},
+		{TraditionalChinese, "Traditional Chinese", LeftToRight, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.lang.Name(); got != tt.name {
+			t.Errorf("%v.Name() = %v, want %v", tt.lang.Shortcut(), got, tt.name)
+		}
+		if got := tt.lang.Direction(); got != tt.direction {
+			t.Errorf("%v.Direction() = %v, want %v", tt.lang.Shortcut(), got, tt.direction)
+		}
+		if got := tt.lang.Parent(); got != tt.parent {
+			t.Errorf("%v.Parent() = %v, want %v", tt.lang.Shortcut(), got, tt.parent)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package language
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/**
+* Tag is a BCP 47 language tag in canonical form,
+* see https://tools.ietf.org/html/rfc5646
+**/
+type Tag struct {
+	Language string // lower case, e.g. "zh"
+	Script   string // title case, e.g. "Hant"
+	Region   string // upper case or UN M.49 digits, e.g. "TW", "419"
+	Variant  string // lower case variants joined by "-", e.g. "rozaj-biske"
+	// Extension holds the extensions and the private use subtags, e.g. "u-ca-buddhist-x-test"
+	Extension string
+}
+
+// deprecated language subtags and their preferred values
+var languageAliases = map[string]string{
+	"in": "id",
+	"iw": "he",
+	"ji": "yi",
+	"jw": "jv",
+	"mo": "ro",
+}
+
+// deprecated region subtags and their preferred values
+var regionAliases = map[string]string{
+	"BU": "MM",
+	"DD": "DE",
+	"FX": "FR",
+	"TP": "TL",
+	"YD": "YE",
+	"ZR": "CD",
+}
+
+// grandfathered tags with a preferred value
+var grandfathered = map[string]string{
+	"art-lojban":  "jbo",
+	"en-gb-oed":   "en-GB-oxendict",
+	"i-ami":       "ami",
+	"i-bnn":       "bnn",
+	"i-hak":       "hak",
+	"i-klingon":   "tlh",
+	"i-lux":       "lb",
+	"i-navajo":    "nv",
+	"i-pwn":       "pwn",
+	"i-tao":       "tao",
+	"i-tay":       "tay",
+	"i-tsu":       "tsu",
+	"no-bok":      "nb",
+	"no-nyn":      "nn",
+	"sgn-be-fr":   "sfb",
+	"sgn-be-nl":   "vgt",
+	"sgn-ch-de":   "sgg",
+	"zh-guoyu":    "cmn",
+	"zh-hakka":    "hak",
+	"zh-min-nan":  "nan",
+	"zh-xiang":    "hsn",
+	"zh-min":      "nan",
+	"cel-gaulish": "xtg",
+}
+
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isAlnum(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Parse parses and canonicalizes a BCP 47 tag, "_" is accepted as separator
+func Parse(s string) (Tag, error) {
+	var tag Tag
+	norm := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(s, "_", "-")))
+	if preferred, ok := grandfathered[norm]; ok {
+		norm = strings.ToLower(preferred)
+	}
+	if norm == "" {
+		return tag, fmt.Errorf("Invalid language tag: %q", s)
+	}
+	subtags := strings.Split(norm, "-")
+	i := 0
+	next := func() string {
+		if i < len(subtags) {
+			return subtags[i]
+		}
+		return ""
+	}
+	invalid := func() (Tag, error) {
+		return Tag{}, fmt.Errorf("Invalid language tag: %q", s)
+	}
+	// a private use tag has no language
+	if next() != "x" {
+		lang := next()
+		if !isAlpha(lang) || len(lang) < 2 || len(lang) > 8 {
+			return invalid()
+		}
+		tag.Language = lang
+		i++
+		// extended language subtags, the first one is the preferred language
+		if len(lang) <= 3 {
+			for n := 0; n < 3 && len(next()) == 3 && isAlpha(next()); n++ {
+				if n == 0 {
+					tag.Language = next()
+				}
+				i++
+			}
+		}
+		if preferred, ok := languageAliases[tag.Language]; ok {
+			tag.Language = preferred
+		}
+		if sub := next(); len(sub) == 4 && isAlpha(sub) {
+			tag.Script = strings.ToUpper(sub[:1]) + sub[1:]
+			i++
+		}
+		if sub := next(); len(sub) == 2 && isAlpha(sub) || len(sub) == 3 && isDigits(sub) {
+			tag.Region = strings.ToUpper(sub)
+			if preferred, ok := regionAliases[tag.Region]; ok {
+				tag.Region = preferred
+			}
+			i++
+		}
+		var variants []string
+		seen := make(map[string]bool)
+		for {
+			sub := next()
+			if !isAlnum(sub) || !(len(sub) >= 5 && len(sub) <= 8 || len(sub) == 4 && isDigits(sub[:1])) {
+				break
+			}
+			if seen[sub] {
+				return invalid()
+			}
+			seen[sub] = true
+			variants = append(variants, sub)
+			i++
+		}
+		tag.Variant = strings.Join(variants, "-")
+	}
+	// extensions, sorted by singleton, followed by private use
+	var (
+		extensions []string
+		singletons = make(map[string]bool)
+	)
+	for i < len(subtags) {
+		singleton := next()
+		if len(singleton) != 1 || !isAlnum(singleton) {
+			return invalid()
+		}
+		i++
+		if singleton == "x" {
+			if i == len(subtags) {
+				return invalid()
+			}
+			for _, sub := range subtags[i:] {
+				if !isAlnum(sub) || len(sub) > 8 {
+					return invalid()
+				}
+			}
+			sort.Strings(extensions)
+			extensions = append(extensions, "x-"+strings.Join(subtags[i:], "-"))
+			i = len(subtags)
+			break
+		}
+		if singletons[singleton] {
+			return invalid()
+		}
+		singletons[singleton] = true
+		start := i
+		for i < len(subtags) && len(next()) >= 2 && len(next()) <= 8 && isAlnum(next()) {
+			i++
+		}
+		if i == start {
+			return invalid()
+		}
+		extensions = append(extensions, singleton+"-"+strings.Join(subtags[start:i], "-"))
+	}
+	if len(extensions) > 0 && !strings.HasPrefix(extensions[len(extensions)-1], "x-") {
+		sort.Strings(extensions)
+	}
+	tag.Extension = strings.Join(extensions, "-")
+	return tag, nil
+}
+
+func MustParse(s string) Tag {
+	tag, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return tag
+}
+
+// String returns the canonical form of the tag, Parse(tag.String()) returns the same tag
+func (t Tag) String() string {
+	parts := make([]string, 0, 5)
+	for _, part := range []string{t.Language, t.Script, t.Region, t.Variant, t.Extension} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+func (t Tag) IsZero() bool {
+	return t == Tag{}
+}
+
+// likely scripts of the languages, see CLDR likelySubtags
+var likelyScripts = map[string]string{
+	"af": "Latn", "am": "Ethi", "ar": "Arab", "az": "Latn", "bg": "Cyrl", "bn": "Beng", "bs": "Latn",
+	"ca": "Latn", "cs": "Latn", "da": "Latn", "de": "Latn", "el": "Grek", "en": "Latn", "es": "Latn",
+	"et": "Latn", "fa": "Arab", "fi": "Latn", "fil": "Latn", "fr": "Latn", "gu": "Gujr", "he": "Hebr",
+	"hi": "Deva", "hr": "Latn", "hu": "Latn", "hy": "Armn", "id": "Latn", "is": "Latn", "it": "Latn",
+	"ja": "Jpan", "ka": "Geor", "kk": "Cyrl", "km": "Khmr", "kn": "Knda", "ko": "Kore", "ky": "Cyrl",
+	"lo": "Laoo", "lt": "Latn", "lv": "Latn", "mk": "Cyrl", "ml": "Mlym", "mn": "Cyrl", "mr": "Deva",
+	"ms": "Latn", "my": "Mymr", "nb": "Latn", "ne": "Deva", "nl": "Latn", "nn": "Latn", "no": "Latn",
+	"pa": "Guru", "pl": "Latn", "pt": "Latn", "ro": "Latn", "ru": "Cyrl", "si": "Sinh", "sk": "Latn",
+	"sl": "Latn", "sq": "Latn", "sr": "Cyrl", "sv": "Latn", "sw": "Latn", "ta": "Taml", "te": "Telu",
+	"th": "Thai", "tl": "Latn", "tr": "Latn", "uk": "Cyrl", "ur": "Arab", "uz": "Latn", "vi": "Latn",
+	"yue": "Hant", "zh": "Hans", "zu": "Latn",
+}
+
+// regions using another script than the likely one of the language
+var likelyRegionScripts = map[string]string{
+	"az-IR":  "Arab",
+	"pa-PK":  "Arab",
+	"sr-ME":  "Latn",
+	"uz-AF":  "Arab",
+	"yue-CN": "Hans",
+	"zh-HK":  "Hant",
+	"zh-MO":  "Hant",
+	"zh-TW":  "Hant",
+}
+
+// likely regions of language and script, only for languages with regional clusters
+var likelyRegions = map[string]string{
+	"en":      "US",
+	"es":      "ES",
+	"pt":      "BR",
+	"zh-Hans": "CN",
+	"zh-Hant": "TW",
+}
+
+// maximize fills the script and region with their likely values
+func maximize(tag Tag) Tag {
+	if tag.Script == "" {
+		if script, ok := likelyRegionScripts[tag.Language+"-"+tag.Region]; ok {
+			tag.Script = script
+		} else {
+			tag.Script = likelyScripts[tag.Language]
+		}
+	}
+	if tag.Region == "" {
+		if region, ok := likelyRegions[tag.Language+"-"+tag.Script]; ok {
+			tag.Region = region
+		} else if region, ok := likelyRegions[tag.Language]; ok && tag.Script == likelyScripts[tag.Language] {
+			tag.Region = region
+		}
+	}
+	return tag
+}
@@ -0,0 +1,51 @@
+package language
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"en", "en"},
+		{"EN-us", "en-US"},
+		{"zh_hant_tw", "zh-Hant-TW"},
+		{"es-419", "es-419"},
+		{"sr-latn-rs", "sr-Latn-RS"},
+		// deprecated subtags are replaced
+		{"iw-IL", "he-IL"},
+		{"in", "id"},
+		{"de-DD", "de-DE"},
+		// extended language subtags
+		{"zh-yue-HK", "yue-HK"},
+		// grandfathered tags
+		{"i-klingon", "tlh"},
+		{"zh-min-nan", "nan"},
+		{"sl-rozaj-biske-1994", "sl-rozaj-biske-1994"},
+		// extensions are sorted by singleton, private use stays last
+		{"en-u-ca-buddhist-a-bbb-x-priv", "en-a-bbb-u-ca-buddhist-x-priv"},
+		{"x-private", "x-private"},
+	}
+	for _, tt := range tests {
+		tag, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got := tag.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		// the canonical form parses to the same tag
+		if again, err := Parse(tag.String()); err != nil || again != tag {
+			t.Errorf("Parse(%q) = %+v, %v, want %+v", tag.String(), again, err, tag)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, in := range []string{"", "e", "en-", "toolonglanguage", "en-a", "en-u-ca-u-nu", "sl-rozaj-rozaj", "en-x", "1en"} {
+		if tag, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", in, tag)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package i18n
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+/**
+* Layer is a source of translation files, like a shared base catalog, the catalog of a service or customer overrides,
+* the layers are merged in order and the keys of a later layer override the same keys of the earlier ones
+**/
+type Layer struct {
+	// Name is reported by KeyLayer, the directory is used if it is empty
+	Name string
+	// FS holds the files, the os file system is used if it is nil
+	FS fs.FS
+	// Dir is the language directory, a path inside FS if it is set
+	Dir string
+	// Source replaces the files of FS and Dir if it is set
+	Source Source
+}
+
+// DirLayer returns a layer reading the language directory dir of the os file system
+func DirLayer(name string, dir string) Layer {
+	return Layer{Name: name, Dir: dir}
+}
+
+// FSLayer returns a layer reading the language directory dir inside fsys, e.g. with //go:embed i18n
+func FSLayer(name string, fsys fs.FS, dir string) Layer {
+	return Layer{Name: name, FS: fsys, Dir: dir}
+}
+
+// SourceLayer returns a layer fetching the dicts from source, like a database
+func SourceLayer(name string, source Source) Layer {
+	return Layer{Name: name, Source: source}
+}
+
+// fileSystem returns the file system and the root of the translation files in it
+func (layer Layer) fileSystem() (fs.FS, string) {
+	if layer.FS == nil {
+		return os.DirFS(layer.Dir), "."
+	}
+	root := strings.Trim(path.Clean(filepath.ToSlash(layer.Dir)), "/")
+	if root == "" {
+		root = "."
+	}
+	return layer.FS, root
+}
+
+func (layer Layer) name() string {
+	if layer.Name != "" {
+		return layer.Name
+	}
+	if layer.Source != nil {
+		return fmt.Sprintf("%T", layer.Source)
+	}
+	return layer.Dir
+}
+
+/**
+* AddLayer adds a layer on top of the added ones, its keys take precedence,
+* the language directory stays the base layer under all the added ones,
+* call SetLanguageDir("") without SetFS to load the added layers only
+**/
+func (opts *I18nOpts) AddLayer(layer Layer) {
+	opts.layers = append(opts.layers, layer)
+}
+
+// loadLayers returns the layers in precedence order, the language directory first
+func (opts *I18nOpts) loadLayers() []Layer {
+	layers := make([]Layer, 0, len(opts.layers)+1)
+	if opts.dir != "" || opts.fsys != nil {
+		layers = append(layers, Layer{FS: opts.fsys, Dir: opts.dir})
+	}
+	return append(layers, opts.layers...)
+}
+
+/**
+* KeyLayer returns the name of the layer serving the key and the language serving it, following the fallback chain,
+* namespace is the namespace without the language prefix, empty if namespace mode is disabled
+**/
+func (b *Bundle) KeyLayer(shortcut string, key string, namespace string) (string, language.I18nLang, bool) {
+	lang := b.opts.GetTargetLang()
+	if b.opts.IsEnabled(shortcut) {
+		lang = language.GetLang(shortcut)
+	}
+	return b.loader.findLayer(lang, key, namespace)
+}
@@ -0,0 +1,110 @@
+package i18n
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLayerPrecedence(t *testing.T) {
+	base := t.TempDir()
+	writeTestFile(t, base, "en.json", `{"language":"en","dict":{"hello":"Hello","bye":"Bye","title":"Files","save":"Save"}}`)
+	writeTestFile(t, base, "ja.json", `{"language":"ja","dict":{"hello":"こんにちは"}}`)
+	service := t.TempDir()
+	writeTestFile(t, service, "en.json", `{"language":"en","dict":{"bye":"See you","title":"Drive"}}`)
+	embedded := fstest.MapFS{
+		"i18n/en.json": {Data: []byte(`{"language":"en","dict":{"title":"Acme Drive"}}`)},
+	}
+	customer := NewMemorySource(&I18nDict{Lang: "en", Dict: dict{"save": "Keep"}})
+
+	opts := NewI18nOpts()
+	opts.SetEnableLangs("en,ja")
+	opts.SetSrcLang("en")
+	opts.SetTargetLang("en")
+	opts.SetLanguageDir(base)
+	opts.AddLayer(DirLayer("service", service))
+	opts.AddLayer(FSLayer("embedded", embedded, "i18n"))
+	opts.AddLayer(SourceLayer("customer", customer))
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	b := NewBundle(opts, log)
+	if err := b.Load(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		lang     string
+		key      string
+		want     string
+		layer    string
+		fromLang string
+	}{
+		// the language directory is the base layer
+		{"en", "hello", "Hello", base, "en"},
+		{"en", "bye", "See you", "service", "en"},
+		{"en", "title", "Acme Drive", "embedded", "en"},
+		{"en", "save", "Keep", "customer", "en"},
+		{"ja", "hello", "こんにちは", base, "ja"},
+		// served by the source language
+		{"ja", "title", "Acme Drive", "embedded", "en"},
+	}
+	for _, tt := range tests {
+		if val := b.NewLocalizer(tt.lang).Trans(tt.key); val != tt.want {
+			t.Errorf("Trans(%v) of %v = %v, want %v", tt.key, tt.lang, val, tt.want)
+		}
+		layer, lang, ok := b.KeyLayer(tt.lang, tt.key, "")
+		if !ok || layer != tt.layer || lang.Shortcut() != tt.fromLang {
+			t.Errorf("KeyLayer(%v, %v) = %v, %v, %v, want %v, %v", tt.lang, tt.key, layer, lang.Shortcut(), ok, tt.layer, tt.fromLang)
+		}
+	}
+	if _, _, ok := b.KeyLayer("en", "missing", ""); ok {
+		t.Errorf("KeyLayer(missing) is found")
+	}
+}
+
+func TestLayerLanguageDir(t *testing.T) {
+	layer := t.TempDir()
+	writeTestFile(t, layer, "en.json", `{"language":"en","dict":{"hello":"Hello"}}`)
+	newBundle := func(dir string) *Bundle {
+		opts := NewI18nOpts()
+		opts.SetEnableLangs("en")
+		opts.SetTargetLang("en")
+		opts.SetLanguageDir(dir)
+		opts.AddLayer(DirLayer("layer", layer))
+		log := logrus.New()
+		log.SetOutput(ioutil.Discard)
+		return NewBundle(opts, log)
+	}
+
+	// the language directory is loaded under the added layers
+	missing := filepath.Join(t.TempDir(), "missing")
+	if err := newBundle(missing).Load(); err == nil {
+		t.Errorf("Load with a missing language directory succeeds")
+	}
+	if err := os.Mkdir(missing, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, missing, "en.json", `{"language":"en","dict":{"bye":"Bye"}}`)
+	b := newBundle(missing)
+	if err := b.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if val := b.Trans("bye"); val != "Bye" {
+		t.Errorf("Trans(bye) = %v, want Bye", val)
+	}
+
+	// an empty language directory loads the added layers only
+	b = newBundle("")
+	if err := b.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if val := b.Trans("hello"); val != "Hello" {
+		t.Errorf("Trans(hello) = %v, want Hello", val)
+	}
+	if val := b.Trans("bye"); val != "bye" {
+		t.Errorf("Trans(bye) = %v, want bye", val)
+	}
+}
@@ -2,149 +2,587 @@ package i18n
 
 import (
 	"fmt"
+	"io/fs"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/yaou-li/go-i18n/language"
+	"github.com/yaou-li/go-i18n/messageformat"
 )
 
+var _ I18nLoader = (*loader)(nil)
+
+/**
+* loader keeps the parsed files and builds an immutable catalog snapshot out of them,
+* snapshots are published atomically so lookups never lock
+**/
 type loader struct {
+	// serializes load and reload
 	sync.Mutex
-	opts               *I18nOpts
-	log                *logrus.Logger
-	parser             I18nParser
-	dicts              map[language.I18nLang]dict
-	dictsWithNamespace map[language.I18nLang]dictWithNamespace
+	opts  *I18nOpts
+	log   *logrus.Logger
+	files map[fileKey]*loadedFile
+	// the source layers changed since they were fetched, by layer index
+	dirty map[int]bool
+	// holds the current *catalog
+	catalog atomic.Value
+}
+
+// fileKey identifies a file by the index of its layer and its path in the layer
+type fileKey struct {
+	layer int
+	path  string
+}
+
+type loadedFile struct {
+	modTime   time.Time
+	size      int64
+	namespace string
+	// data is the last successfully parsed content
+	data *I18nDict
+}
+
+// catalog is never modified once published
+type catalog struct {
+	dicts                map[language.I18nLang]dict
+	dictsWithNamespace   map[language.I18nLang]dictWithNamespace
+	plurals              map[language.I18nLang]pluralDict
+	pluralsWithNamespace map[language.I18nLang]pluralDictWithNamespace
+	// the layer name of each key, by language and namespace, the namespace is empty for the general dict
+	layers map[language.I18nLang]map[string]map[string]string
+	// parsed ICU messages, keyed by the raw translation
+	messages map[string]*messageformat.Message
+}
+
+func newCatalog() *catalog {
+	return &catalog{
+		dicts:                make(map[language.I18nLang]dict),
+		dictsWithNamespace:   make(map[language.I18nLang]dictWithNamespace),
+		plurals:              make(map[language.I18nLang]pluralDict),
+		pluralsWithNamespace: make(map[language.I18nLang]pluralDictWithNamespace),
+		layers:               make(map[language.I18nLang]map[string]map[string]string),
+		messages:             make(map[string]*messageformat.Message),
+	}
 }
 
 func Newloader(opts *I18nOpts, log *logrus.Logger) *loader {
-	return &loader{
-		opts:               opts,
-		log:                log,
-		parser:             ParserFactory(opts),
-		dicts:              make(map[language.I18nLang]dict),
-		dictsWithNamespace: make(map[language.I18nLang]dictWithNamespace),
+	l := &loader{
+		opts:  opts,
+		log:   log,
+		files: make(map[fileKey]*loadedFile),
+		dirty: make(map[int]bool),
 	}
+	l.catalog.Store(newCatalog())
+	return l
 }
 
+// load reads all files, the files that fail to parse are skipped and parsed again by the next refresh
 func (l *loader) load() error {
-	var s []string
-	// read all files
-	files, err := ReadAllPath(l.opts.dir, s, l.opts.fileType)
+	l.Lock()
+	defer l.Unlock()
+	res, err := l.scan(true)
 	if err != nil {
 		l.log.Error(err)
-		return err
 	}
-	// loop through each file and save in dicts or dictsWithNamespace accordingly
+	if res != nil {
+		l.commit(res)
+	}
+	c, buildErr := l.build(l.files)
+	l.swap(c)
+	if err == nil {
+		err = buildErr
+	}
+	return err
+}
+
+// reload re-parses the changed files, the catalog is kept if any of them fails to parse
+func (l *loader) reload() error {
+	_, err := l.refresh(true)
+	return err
+}
+
+/**
+* refresh scans the files and swaps in a new catalog if anything changed,
+* it returns the changed files, the scan is only kept if the catalog is swapped in,
+* so the changes are seen again by the next refresh and the broken files are reported until they are fixed
+**/
+func (l *loader) refresh(force bool) ([]string, error) {
 	l.Lock()
 	defer l.Unlock()
-	for _, fpath := range files {
-		data, err := l.parser.parse(fpath)
+	res, err := l.scan(force)
+	if err != nil {
+		if res == nil {
+			return nil, err
+		}
+		return res.changed, err
+	}
+	if len(res.changed) > 0 || force {
+		c, err := l.build(res.files)
 		if err != nil {
-			l.log.Errorf("Fail to parser file: %v, error: %v", fpath, err)
-			return err
+			return res.changed, err
 		}
+		l.swap(c)
+	}
+	l.commit(res)
+	return res.changed, nil
+}
+
+// scanResult is the state of the files after a scan, the loader state is only replaced once it is committed
+type scanResult struct {
+	files map[fileKey]*loadedFile
+	// the source layers fetched by the scan
+	fetched []int
+	// the added, modified or removed files
+	changed []string
+}
+
+func (l *loader) commit(res *scanResult) {
+	l.files = res.files
+	for _, i := range res.fetched {
+		delete(l.dirty, i)
+	}
+}
+
+/**
+* scan parses the new and modified files of all layers and forgets the removed ones,
+* the source layers are fetched again if forced or if they notified a change,
+* the changed files of added layers are prefixed with the layer name, as name:path,
+* a file failing to parse keeps its previous entry, the loaded files are never modified
+**/
+func (l *loader) scan(force bool) (*scanResult, error) {
+	var errs []string
+	// the format is looked up on each scan since formats can be registered after the loader is created
+	parser, err := ParserFactory(l.opts)
+	if err != nil {
+		return nil, err
+	}
+	res := &scanResult{files: make(map[fileKey]*loadedFile, len(l.files))}
+	for key, file := range l.files {
+		res.files[key] = file
+	}
+	layers := l.opts.loadLayers()
+	display := func(key fileKey) string {
+		if len(l.opts.layers) == 0 {
+			return key.path
+		}
+		return layers[key.layer].name() + ":" + key.path
+	}
+	seen := make(map[fileKey]bool)
+	for i, layer := range layers {
+		if layer.Source != nil {
+			refetch := force || l.dirty[i]
+			keys, fetched, err := l.fetch(i, layer.Source, refetch, res.files)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%v: %v", layer.name(), err))
+			} else if refetch {
+				res.fetched = append(res.fetched, i)
+			}
+			for _, key := range keys {
+				seen[key] = true
+			}
+			for _, key := range fetched {
+				res.changed = append(res.changed, display(key))
+			}
+			continue
+		}
+		var s []string
+		fsys, root := layer.fileSystem()
+		paths, err := ReadAllFSPath(fsys, root, s, l.opts.fileType)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%v: %v", layer.name(), err))
+			// the files of an unreadable layer are kept
+			for key := range res.files {
+				if key.layer == i {
+					seen[key] = true
+				}
+			}
+			continue
+		}
+		for _, fpath := range paths {
+			key := fileKey{i, fpath}
+			seen[key] = true
+			info, err := fs.Stat(fsys, fpath)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%v: %v", display(key), err))
+				continue
+			}
+			file, ok := res.files[key]
+			if ok && file.modTime.Equal(info.ModTime()) && file.size == info.Size() {
+				continue
+			}
+			res.changed = append(res.changed, display(key))
+			data, err := parser.parse(fsys, root, fpath)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%v: %v", display(key), err))
+				continue
+			}
+			res.files[key] = &loadedFile{
+				modTime:   info.ModTime(),
+				size:      info.Size(),
+				namespace: fileNamespace(fpath, root, l.opts.fileType, l.opts.splitter),
+				data:      data,
+			}
+		}
+	}
+	for key := range res.files {
+		if !seen[key] {
+			delete(res.files, key)
+			res.changed = append(res.changed, display(key))
+		}
+	}
+	if len(errs) > 0 {
+		return res, fmt.Errorf("Fail to parse files: %v", strings.Join(errs, "; "))
+	}
+	return res, nil
+}
+
+/**
+* fetch reads the dicts of the enabled languages of a source layer into files, they replace the dicts fetched before,
+* it returns the keys of the dicts of the layer and the keys replaced or added by the fetch,
+* the previous dicts are kept if the source fails
+**/
+func (l *loader) fetch(i int, source Source, refetch bool, files map[fileKey]*loadedFile) ([]fileKey, []fileKey, error) {
+	var keys []fileKey
+	for key := range files {
+		if key.layer == i {
+			keys = append(keys, key)
+		}
+	}
+	if !refetch {
+		return keys, nil, nil
+	}
+	langs, err := source.Languages()
+	if err != nil {
+		return keys, nil, err
+	}
+	fetchedFiles := make(map[fileKey]*loadedFile)
+	for _, lang := range langs {
+		if !l.opts.IsEnabled(lang) {
+			continue
+		}
+		dicts, err := source.Fetch(lang)
+		if err != nil {
+			return keys, nil, err
+		}
+		for j, d := range dicts {
+			if d.Lang == "" {
+				d.Lang = lang
+			}
+			// the dicts are merged in the order of the source
+			key := fileKey{i, fmt.Sprintf("%v/%06d", lang, j)}
+			fetchedFiles[key] = &loadedFile{namespace: d.Namespace, data: d}
+		}
+	}
+	// the removed dicts are reported as well
+	fetched := keys
+	for _, key := range keys {
+		if _, ok := fetchedFiles[key]; !ok {
+			delete(files, key)
+		}
+	}
+	keys = make([]fileKey, 0, len(fetchedFiles))
+	for key, file := range fetchedFiles {
+		if _, ok := files[key]; !ok {
+			fetched = append(fetched, key)
+		}
+		files[key] = file
+		keys = append(keys, key)
+	}
+	return keys, fetched, nil
+}
+
+// markDirty makes the next refresh fetch the source layer again
+func (l *loader) markDirty(i int) {
+	l.Lock()
+	defer l.Unlock()
+	l.dirty[i] = true
+}
+
+/**
+* build merges the parsed files into a new catalog, in layer order and then in path order,
+* the placeholder mismatches are logged, and returned as an error with strict placeholders
+**/
+func (l *loader) build(files map[fileKey]*loadedFile) (*catalog, error) {
+	c := newCatalog()
+	layers := l.opts.loadLayers()
+	keys := make([]fileKey, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].layer != keys[j].layer {
+			return keys[i].layer < keys[j].layer
+		}
+		return keys[i].path < keys[j].path
+	})
+	for _, key := range keys {
+		file := files[key]
+		if file.data == nil {
+			continue
+		}
+		layer := layers[key.layer].name()
 		// check if lang is valid
-		if !l.opts.IsEnabled(data.Lang) {
-			l.log.Errorf("Unsupported language: %v", data.Lang)
+		if !l.opts.IsEnabled(file.data.Lang) {
+			l.log.Errorf("Unsupported language: %v", file.data.Lang)
 			continue
 		}
 		// store in dicts with namespace if enabled, store in dicts otherwise
-		if l.opts.enableNamespace {
-			l.mergeWithNameSpace(fpath, data)
+		if l.opts.enableNamespace && file.namespace != "" {
+			if file.namespace != file.data.Namespace {
+				l.log.Errorf("Failed to load into namespace, namespace unmatched: %v vs %v", file.namespace, file.data.Namespace)
+				// if namespace is not matched, fallback to general dict
+				c.merge(layer, file.data, l.log)
+			} else {
+				c.mergeWithNameSpace(layer, file.namespace, file.data, l.log)
+			}
 		} else {
-			l.merge(data)
+			c.merge(layer, file.data, l.log)
 		}
 	}
-	return nil
+	errs := c.validatePlaceholders(l.opts.src)
+	for _, err := range errs {
+		l.log.Error(err)
+	}
+	if l.opts.strictPlaceholders && len(errs) > 0 {
+		return c, fmt.Errorf("Invalid placeholders in %d translations, first: %v", len(errs), errs[0])
+	}
+	return c, nil
+}
+
+func (l *loader) swap(c *catalog) {
+	l.catalog.Store(c)
+}
+
+// current returns the latest snapshot, it must not be modified
+func (l *loader) current() *catalog {
+	return l.catalog.Load().(*catalog)
 }
 
-func (l *loader) merge(data *I18nDict) {
+func (c *catalog) merge(layer string, data *I18nDict, log *logrus.Logger) {
 	lang := language.GetLang(data.Lang)
-	if _, ok := l.dicts[lang]; !ok {
-		l.dicts[lang] = make(dict)
+	if _, ok := c.dicts[lang]; !ok {
+		c.dicts[lang] = make(dict)
 	}
 	for k, v := range data.Dict {
-		l.dicts[lang][k] = v
+		if data.IsFuzzy(k) {
+			continue
+		}
+		c.dicts[lang][k] = v
+		c.setLayer(lang, "", k, layer)
+		c.cacheMessage(v, log)
+	}
+	if len(data.Plural) == 0 {
+		return
+	}
+	if _, ok := c.plurals[lang]; !ok {
+		c.plurals[lang] = make(pluralDict)
+	}
+	for k, v := range data.Plural {
+		if data.IsFuzzy(k) {
+			continue
+		}
+		c.plurals[lang][k] = v.clone()
+		c.setLayer(lang, "", k, layer)
 	}
 }
 
-func (l *loader) mergeWithNameSpace(fpath string, data *I18nDict) {
+// mergeWithNameSpace merges into the namespace, the files of several layers may share it
+func (c *catalog) mergeWithNameSpace(layer string, namespace string, data *I18nDict, log *logrus.Logger) {
 	lang := language.GetLang(data.Lang)
-	namespace := GetNamespace(strings.TrimRight(fpath, "."+l.opts.fileType), l.opts.dir, l.opts.splitter)
-	if namespace != data.Namespace {
-		l.log.Error("Failed to load into namespace, namespace unmatched: %v vs %v", namespace, data.Namespace)
-		// if namespace is not matched, fallback to general dict
-		l.merge(data)
-		return
+	if _, ok := c.dictsWithNamespace[lang]; !ok {
+		c.dictsWithNamespace[lang] = make(dictWithNamespace)
 	}
-	if _, ok := l.dictsWithNamespace[lang]; !ok {
-		l.dictsWithNamespace[lang] = make(dictWithNamespace)
+	if _, ok := c.dictsWithNamespace[lang][namespace]; !ok {
+		c.dictsWithNamespace[lang][namespace] = make(dict)
 	}
-	l.dictsWithNamespace[lang][namespace] = make(dict)
 	for k, v := range data.Dict {
-		l.dictsWithNamespace[lang][namespace][k] = v
+		if data.IsFuzzy(k) {
+			continue
+		}
+		c.dictsWithNamespace[lang][namespace][k] = v
+		c.setLayer(lang, namespace, k, layer)
+		c.cacheMessage(v, log)
+	}
+	if _, ok := c.pluralsWithNamespace[lang]; !ok {
+		c.pluralsWithNamespace[lang] = make(pluralDictWithNamespace)
+	}
+	if _, ok := c.pluralsWithNamespace[lang][namespace]; !ok {
+		c.pluralsWithNamespace[lang][namespace] = make(pluralDict)
+	}
+	for k, v := range data.Plural {
+		if data.IsFuzzy(k) {
+			continue
+		}
+		c.pluralsWithNamespace[lang][namespace][k] = v.clone()
+		c.setLayer(lang, namespace, k, layer)
 	}
 }
 
-func (l *loader) reload() error {
-	l.Lock()
-	defer l.Unlock()
-	l.reset()
-	return l.load()
+func (c *catalog) setLayer(lang language.I18nLang, namespace string, key string, layer string) {
+	if _, ok := c.layers[lang]; !ok {
+		c.layers[lang] = make(map[string]map[string]string)
+	}
+	if _, ok := c.layers[lang][namespace]; !ok {
+		c.layers[lang][namespace] = make(map[string]string)
+	}
+	c.layers[lang][namespace][key] = layer
+}
+
+// cacheMessage parses the translation as an ICU message once, invalid messages are only usable as plain strings
+func (c *catalog) cacheMessage(val string, log *logrus.Logger) {
+	if _, ok := c.messages[val]; ok || val == "" {
+		return
+	}
+	msg, err := messageformat.Parse(val)
+	if err != nil {
+		log.Debugf("Failed to parse message: %v, error: %v", val, err)
+		return
+	}
+	c.messages[val] = msg
 }
 
-func (l *loader) reset() {
-	for lang := range l.dicts {
-		delete(l.dicts, lang)
+// message returns the parsed ICU message of the translation, the tenant overrides cache their own messages
+func (l *loader) message(tenant *catalog, val string) (*messageformat.Message, bool) {
+	if tenant != nil {
+		if msg, ok := tenant.messages[val]; ok {
+			return msg, true
+		}
 	}
-	for lang := range l.dictsWithNamespace {
-		delete(l.dictsWithNamespace, lang)
+	msg, ok := l.current().messages[val]
+	return msg, ok
+}
+
+type candidate struct {
+	lang    language.I18nLang
+	dict    dict
+	plurals pluralDict
+	// the layer names of the keys
+	layers map[string]string
+}
+
+/**
+* candidates lists the dicts to search in order,
+* for each language of the fallback chain the namespaced dict comes before the general one,
+* and the dicts of the tenant overrides, if any, come before those of the catalog
+**/
+func (l *loader) candidates(tenant *catalog, lang language.I18nLang, namespace string) []candidate {
+	var res []candidate
+	c := l.current()
+	for _, fallback := range l.opts.FallbackChain(lang) {
+		if tenant != nil {
+			res = tenant.appendCandidates(res, fallback, namespace, l.opts.splitter)
+		}
+		res = c.appendCandidates(res, fallback, namespace, l.opts.splitter)
 	}
+	return res
 }
 
-func (l *loader) get(key string) string {
-	if dict, ok := l.dicts[l.opts.target]; !ok {
-		l.log.Errorf("Missing translation for lang: %v", l.opts.target.Shortcut())
-		return key
-	} else {
-		if val, ok := dict[key]; !ok || val == "" {
-			l.log.Errorf("Missing translation: %v", key)
-			return key
-		} else {
-			return val
+func (c *catalog) appendCandidates(res []candidate, lang language.I18nLang, namespace string, splitter string) []candidate {
+	if namespace != "" {
+		fullNamespace := lang.Shortcut() + splitter + namespace
+		res = append(res, candidate{
+			lang:    lang,
+			dict:    c.dictsWithNamespace[lang][fullNamespace],
+			plurals: c.pluralsWithNamespace[lang][fullNamespace],
+			layers:  c.layers[lang][fullNamespace],
+		})
+	}
+	return append(res, candidate{
+		lang:    lang,
+		dict:    c.dicts[lang],
+		plurals: c.plurals[lang],
+		layers:  c.layers[lang][""],
+	})
+}
+
+/**
+* find returns the translation and the language serving it, namespace is empty if namespace mode is disabled,
+* tenant holds the overrides of the tenant, nil without tenant
+**/
+func (l *loader) find(tenant *catalog, lang language.I18nLang, key string, namespace string) (string, language.I18nLang, bool) {
+	for _, c := range l.candidates(tenant, lang, namespace) {
+		if val, ok := c.dict[key]; ok && val != "" {
+			l.opts.reportFallback(key, lang, c.lang)
+			return val, c.lang, true
 		}
 	}
+	l.log.Errorf("Missing translation: %v, lang: %v, namespace: %v", key, lang.Shortcut(), namespace)
+	return "", lang, false
 }
 
-func (l *loader) getWithNamespace(key string, namespace string) string {
-	if dicts, ok := l.dictsWithNamespace[l.opts.target]; !ok {
-		l.log.Errorf("Missing translation in namespace mode, lang: %v", l.opts.target.Shortcut())
-		// fall back with none namespaced dict
-		return l.get(key)
-	} else {
-		if dict, ok := dicts[namespace]; !ok {
-			l.log.Errorf("Missing translation in namespace %v, for %v", namespace, key)
-			// fall back with none namespaced dict
-			return l.get(key)
-		} else {
-			if val, ok := dict[key]; !ok || val == "" {
-				l.log.Errorf("Missing translation in namespace %v, for %v", namespace, key)
-				// fall back with none namespaced dict
-				return l.get(key)
-			} else {
-				return val
+/**
+* findPlural returns the plural form of count, the category is selected with the rules of the serving language,
+* keys without plural forms are looked up as plain strings
+**/
+func (l *loader) findPlural(tenant *catalog, lang language.I18nLang, key string, count interface{}, namespace string) (string, language.I18nLang, bool) {
+	for _, c := range l.candidates(tenant, lang, namespace) {
+		if forms, ok := c.plurals[key]; ok {
+			category, err := c.lang.PluralCategory(count)
+			if err != nil {
+				l.log.Errorf("Failed to get plural category of key: %v, error: %v", key, err)
+			}
+			if val, ok := forms.get(category); ok {
+				l.opts.reportFallback(key, lang, c.lang)
+				return val, c.lang, true
 			}
 		}
+		if val, ok := c.dict[key]; ok && val != "" {
+			l.opts.reportFallback(key, lang, c.lang)
+			return val, c.lang, true
+		}
+	}
+	l.log.Errorf("Missing plural translation: %v, lang: %v, namespace: %v", key, lang.Shortcut(), namespace)
+	return "", lang, false
+}
+
+// findLayer returns the layer serving the key as a string or a plural, it does not report fallbacks
+func (l *loader) findLayer(lang language.I18nLang, key string, namespace string) (string, language.I18nLang, bool) {
+	for _, c := range l.candidates(nil, lang, namespace) {
+		_, isPlural := c.plurals[key]
+		if val, ok := c.dict[key]; isPlural || (ok && val != "") {
+			return c.layers[key], c.lang, true
+		}
+	}
+	return "", lang, false
+}
+
+func (l *loader) get(lang language.I18nLang, key string) string {
+	return l.getWithNamespace(lang, key, "")
+}
+
+func (l *loader) getWithNamespace(lang language.I18nLang, key string, namespace string) string {
+	if val, _, ok := l.find(nil, lang, key, namespace); ok {
+		return val
+	}
+	return key
+}
+
+// getDicts returns a copy of the current dicts
+func (l *loader) getDicts() map[language.I18nLang]dict {
+	c := l.current()
+	res := make(map[language.I18nLang]dict, len(c.dicts))
+	for lang, d := range c.dicts {
+		res[lang] = make(dict, len(d))
+		for k, v := range d {
+			res[lang][k] = v
+		}
 	}
+	return res
 }
 
+// getDict returns a copy of the dict of lang
 func (l *loader) getDict(lang language.I18nLang) (dict, error) {
-	if dict, ok := l.dicts[lang]; !ok {
+	if d, ok := l.current().dicts[lang]; !ok {
 		return nil, fmt.Errorf("Unloaded dict with lang :%v", lang.Shortcut())
 	} else {
-		return dict, nil
+		res := make(dict, len(d))
+		for k, v := range d {
+			res[k] = v
+		}
+		return res, nil
 	}
-
 }
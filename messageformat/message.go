@@ -0,0 +1,250 @@
+package messageformat
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+// Message is a parsed pattern, it is safe for concurrent use
+type Message struct {
+	source string
+	nodes  []node
+}
+
+func (m *Message) String() string {
+	return m.source
+}
+
+/**
+* Format renders the message with the plural rules of lang,
+* missing or invalid arguments are rendered as {name} and reported in the error
+**/
+func (m *Message) Format(lang language.I18nLang, args map[string]interface{}) (string, error) {
+	f := &formatter{lang: lang, args: args}
+	f.format(m.nodes, nil)
+	return f.buf.String(), f.err
+}
+
+type node interface{}
+
+type textNode string
+
+type poundNode struct{}
+
+type argNode struct {
+	name string
+}
+
+type numberNode struct {
+	name  string
+	style string
+}
+
+type pluralNode struct {
+	name   string
+	offset int
+	cases  map[string][]node
+}
+
+type selectNode struct {
+	name  string
+	cases map[string][]node
+}
+
+type formatter struct {
+	lang language.I18nLang
+	args map[string]interface{}
+	buf  strings.Builder
+	err  error
+}
+
+func (f *formatter) fail(err error) {
+	if f.err == nil {
+		f.err = err
+	}
+}
+
+func (f *formatter) arg(name string) (interface{}, bool) {
+	val, ok := f.args[name]
+	if !ok {
+		f.fail(fmt.Errorf("Missing message argument: %v", name))
+		f.buf.WriteString("{" + name + "}")
+	}
+	return val, ok
+}
+
+// format writes the nodes, pound is the offset value of the innermost plural
+func (f *formatter) format(nodes []node, pound *float64) {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case textNode:
+			f.buf.WriteString(string(n))
+		case poundNode:
+			if pound != nil {
+				f.buf.WriteString(formatNumber(f.lang, *pound, 3))
+			} else {
+				f.buf.WriteByte('#')
+			}
+		case argNode:
+			if val, ok := f.arg(n.name); ok {
+				f.buf.WriteString(formatValue(val))
+			}
+		case numberNode:
+			val, ok := f.arg(n.name)
+			if !ok {
+				continue
+			}
+			num, err := toFloat(val)
+			if err != nil {
+				f.fail(err)
+				f.buf.WriteString(formatValue(val))
+				continue
+			}
+			// the numbers use the decimal and group separators of the language
+			switch n.style {
+			case "integer":
+				f.buf.WriteString(formatNumber(f.lang, math.Round(num), 0))
+			case "percent":
+				f.buf.WriteString(formatNumber(f.lang, math.Round(num*100), 0) + "%")
+			default:
+				f.buf.WriteString(formatNumber(f.lang, num, 3))
+			}
+		case pluralNode:
+			f.formatPlural(n)
+		case selectNode:
+			val, ok := f.arg(n.name)
+			if !ok {
+				continue
+			}
+			nodes, ok := n.cases[fmt.Sprint(val)]
+			if !ok {
+				nodes = n.cases["other"]
+			}
+			f.format(nodes, pound)
+		}
+	}
+}
+
+func (f *formatter) formatPlural(n pluralNode) {
+	val, ok := f.arg(n.name)
+	if !ok {
+		return
+	}
+	num, err := toFloat(val)
+	if err != nil {
+		f.fail(err)
+		f.buf.WriteString(formatValue(val))
+		return
+	}
+	// explicit values match the number before the offset is applied
+	if nodes, ok := n.cases["="+formatFloat(num)]; ok {
+		pound := num - float64(n.offset)
+		f.format(nodes, &pound)
+		return
+	}
+	pound := num - float64(n.offset)
+	count := interface{}(pound)
+	if n.offset == 0 {
+		// keep the visible fraction digits of string and integer counts
+		count = val
+	}
+	category, err := f.lang.PluralCategory(count)
+	if err != nil {
+		f.fail(err)
+	}
+	nodes, ok := n.cases[string(category)]
+	if !ok {
+		nodes = n.cases["other"]
+	}
+	f.format(nodes, &pound)
+}
+
+func toFloat(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case int:
+		return float64(v), nil
+	case int8:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint8:
+		return float64(v), nil
+	case uint16:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		if num, err := strconv.ParseFloat(v, 64); err == nil {
+			return num, nil
+		}
+	}
+	return 0, fmt.Errorf("Invalid number argument: %v", val)
+}
+
+func formatFloat(num float64) string {
+	return strconv.FormatFloat(num, 'f', -1, 64)
+}
+
+func formatValue(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return formatFloat(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Args returns the argument names used by the message, in order of first use
+func (m *Message) Args() []string {
+	var names []string
+	seen := make(map[string]bool)
+	var walk func(nodes []node)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	walk = func(nodes []node) {
+		for _, n := range nodes {
+			switch n := n.(type) {
+			case argNode:
+				add(n.name)
+			case numberNode:
+				add(n.name)
+			case pluralNode:
+				add(n.name)
+				for _, c := range n.cases {
+					walk(c)
+				}
+			case selectNode:
+				add(n.name)
+				for _, c := range n.cases {
+					walk(c)
+				}
+			}
+		}
+	}
+	walk(m.nodes)
+	return names
+}
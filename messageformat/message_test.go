@@ -0,0 +1,79 @@
+package messageformat
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		pattern string
+		lang    language.I18nLang
+		args    map[string]interface{}
+		want    string
+	}{
+		{"Hello {name}", language.English, map[string]interface{}{"name": "Ann"}, "Hello Ann"},
+		{"{n, number} files", language.English, map[string]interface{}{"n": 1234.5}, "1,234.5 files"},
+		{"{n, number, integer}", language.German, map[string]interface{}{"n": 1234.5}, "1.235"},
+		{"{n, number, percent}", language.English, map[string]interface{}{"n": 0.256}, "26%"},
+		{"{n, plural, one {# file} other {# files}}", language.English, map[string]interface{}{"n": 1}, "1 file"},
+		{"{n, plural, one {# file} other {# files}}", language.English, map[string]interface{}{"n": 1200}, "1,200 files"},
+		{"{n, plural, =0 {none} one {# file} other {# files}}", language.English, map[string]interface{}{"n": 0}, "none"},
+		{"{n, plural, one {# файл} few {# файла} many {# файлов} other {# файла}}", language.Russian, map[string]interface{}{"n": 22}, "22 файла"},
+		{"{n, plural, offset:1 =1 {you} one {you and # other} other {you and # others}}", language.English, map[string]interface{}{"n": 3}, "you and 2 others"},
+		// # in a select nested in a plural is the plural value
+		{"{n, plural, other {{g, select, female {she has # cats} other {they have # cats}}}}", language.English, map[string]interface{}{"n": 2, "g": "female"}, "she has 2 cats"},
+		{"{g, select, male {he} female {she} other {they}}", language.English, map[string]interface{}{"g": "x"}, "they"},
+		// # outside of a plural is literal
+		{"# {n}", language.English, map[string]interface{}{"n": 1}, "# 1"},
+		{"l'{object}'", language.French, map[string]interface{}{"object": "x"}, "l{object}"},
+		{"it''s {n}", language.English, map[string]interface{}{"n": 1}, "it's 1"},
+		{"l'ami {name}", language.French, map[string]interface{}{"name": "Ann"}, "l'ami Ann"},
+		{"{n, plural, other {'#' #}}", language.English, map[string]interface{}{"n": 3}, "# 3"},
+	}
+	for _, tt := range tests {
+		msg, err := Parse(tt.pattern)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.pattern, err)
+			continue
+		}
+		got, err := msg.Format(tt.lang, tt.args)
+		if err != nil {
+			t.Errorf("Format(%q) error: %v", tt.pattern, err)
+		}
+		if got != tt.want {
+			t.Errorf("Format(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestFormatMissingArgument(t *testing.T) {
+	msg := MustParse("Hello {name}")
+	got, err := msg.Format(language.English, nil)
+	if err == nil || got != "Hello {name}" {
+		t.Errorf("Format without args = %q, %v, want the placeholder and an error", got, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, pattern := range []string{
+		"{name",
+		"}",
+		"{n, plural, one {x}}",
+		"{n, plural, other {x}",
+		"{n, unknown}",
+	} {
+		if _, err := Parse(pattern); err == nil {
+			t.Errorf("Parse(%q) succeeded", pattern)
+		}
+	}
+}
+
+func TestArgs(t *testing.T) {
+	msg := MustParse("{a} {n, plural, other {{b} {g, select, x {{c}} other {}}}} {a}")
+	if got, want := msg.Args(), []string{"a", "n", "b", "g", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Args() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,126 @@
+package messageformat
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+// numberSymbols are the CLDR symbols of the latin digits of a locale
+type numberSymbols struct {
+	decimal string
+	group   string
+	// minGrouping is the number of integer digits below which no group separator is written
+	minGrouping int
+	// indian grouping keeps groups of two digits above the thousands, 12,34,567
+	indian bool
+}
+
+var defaultSymbols = numberSymbols{decimal: ".", group: ",", minGrouping: 4}
+
+// numberSymbolTable is keyed by language or language-region, the region takes precedence
+var numberSymbolTable = map[string]numberSymbols{
+	"de":     {decimal: ",", group: ".", minGrouping: 4},
+	"de-CH":  {decimal: ".", group: "’", minGrouping: 4},
+	"da":     {decimal: ",", group: ".", minGrouping: 4},
+	"nl":     {decimal: ",", group: ".", minGrouping: 4},
+	"it":     {decimal: ",", group: ".", minGrouping: 4},
+	"es":     {decimal: ",", group: ".", minGrouping: 5},
+	"es-MX":  {decimal: ".", group: ",", minGrouping: 4},
+	"es-419": {decimal: ".", group: ",", minGrouping: 4},
+	"pt":     {decimal: ",", group: ".", minGrouping: 4},
+	"pt-PT":  {decimal: ",", group: " ", minGrouping: 5},
+	"id":     {decimal: ",", group: ".", minGrouping: 4},
+	"tr":     {decimal: ",", group: ".", minGrouping: 4},
+	"el":     {decimal: ",", group: ".", minGrouping: 4},
+	"ro":     {decimal: ",", group: ".", minGrouping: 4},
+	"hr":     {decimal: ",", group: ".", minGrouping: 4},
+	"sl":     {decimal: ",", group: ".", minGrouping: 4},
+	"sr":     {decimal: ",", group: ".", minGrouping: 4},
+	"vi":     {decimal: ",", group: ".", minGrouping: 4},
+	"ca":     {decimal: ",", group: ".", minGrouping: 4},
+	"az":     {decimal: ",", group: ".", minGrouping: 4},
+	"is":     {decimal: ",", group: ".", minGrouping: 4},
+	"fr":     {decimal: ",", group: " ", minGrouping: 4},
+	"fr-CA":  {decimal: ",", group: " ", minGrouping: 4},
+	"ru":     {decimal: ",", group: " ", minGrouping: 4},
+	"uk":     {decimal: ",", group: " ", minGrouping: 4},
+	"bg":     {decimal: ",", group: " ", minGrouping: 5},
+	"pl":     {decimal: ",", group: " ", minGrouping: 5},
+	"cs":     {decimal: ",", group: " ", minGrouping: 4},
+	"sk":     {decimal: ",", group: " ", minGrouping: 4},
+	"fi":     {decimal: ",", group: " ", minGrouping: 4},
+	"sv":     {decimal: ",", group: " ", minGrouping: 4},
+	"nb":     {decimal: ",", group: " ", minGrouping: 4},
+	"no":     {decimal: ",", group: " ", minGrouping: 4},
+	"hu":     {decimal: ",", group: " ", minGrouping: 4},
+	"lt":     {decimal: ",", group: " ", minGrouping: 4},
+	"lv":     {decimal: ",", group: " ", minGrouping: 4},
+	"et":     {decimal: ",", group: " ", minGrouping: 5},
+	"kk":     {decimal: ",", group: " ", minGrouping: 4},
+	"ky":     {decimal: ",", group: " ", minGrouping: 4},
+	"hy":     {decimal: ",", group: " ", minGrouping: 4},
+	"ka":     {decimal: ",", group: " ", minGrouping: 4},
+	"af":     {decimal: ",", group: " ", minGrouping: 4},
+	"hi":     {decimal: ".", group: ",", minGrouping: 4, indian: true},
+	"bn":     {decimal: ".", group: ",", minGrouping: 4, indian: true},
+	"gu":     {decimal: ".", group: ",", minGrouping: 4, indian: true},
+	"kn":     {decimal: ".", group: ",", minGrouping: 4, indian: true},
+	"pa":     {decimal: ".", group: ",", minGrouping: 4, indian: true},
+}
+
+func symbolsOf(lang language.I18nLang) numberSymbols {
+	tag := lang.Tag()
+	if tag.Region != "" {
+		if s, ok := numberSymbolTable[tag.Language+"-"+tag.Region]; ok {
+			return s
+		}
+	}
+	if s, ok := numberSymbolTable[tag.Language]; ok {
+		return s
+	}
+	return defaultSymbols
+}
+
+/**
+* formatNumber follows the CLDR decimal pattern #,##0.### of lang,
+* at most maxFraction fraction digits are kept, trailing zeros are dropped
+**/
+func formatNumber(lang language.I18nLang, num float64, maxFraction int) string {
+	if math.IsNaN(num) || math.IsInf(num, 0) {
+		return formatFloat(num)
+	}
+	s := symbolsOf(lang)
+	digits := strconv.FormatFloat(math.Abs(num), 'f', maxFraction, 64)
+	intPart, fraction := digits, ""
+	if idx := strings.IndexByte(digits, '.'); idx >= 0 {
+		intPart, fraction = digits[:idx], strings.TrimRight(digits[idx+1:], "0")
+	}
+	var b strings.Builder
+	if num < 0 && (strings.Trim(intPart, "0") != "" || fraction != "") {
+		b.WriteByte('-')
+	}
+	b.WriteString(groupDigits(intPart, s))
+	if fraction != "" {
+		b.WriteString(s.decimal + fraction)
+	}
+	return b.String()
+}
+
+func groupDigits(digits string, s numberSymbols) string {
+	if len(digits) < s.minGrouping {
+		return digits
+	}
+	var groups []string
+	size := 3
+	for len(digits) > size {
+		groups = append([]string{digits[len(digits)-size:]}, groups...)
+		digits = digits[:len(digits)-size]
+		if s.indian {
+			size = 2
+		}
+	}
+	return strings.Join(append([]string{digits}, groups...), s.group)
+}
@@ -0,0 +1,33 @@
+package messageformat
+
+import (
+	"testing"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+func TestFormatNumber(t *testing.T) {
+	fr := numberSymbolTable["fr"]
+	tests := []struct {
+		lang language.I18nLang
+		num  float64
+		want string
+	}{
+		{language.English, 1234567.891, "1,234,567.891"},
+		{language.English, 999, "999"},
+		{language.English, -0.0001, "0"},
+		{language.English, -12.5, "-12.5"},
+		{language.German, 1234.5, "1.234,5"},
+		{language.French, 1234.5, "1" + fr.group + "234" + fr.decimal + "5"},
+		// spanish groups from five digits
+		{language.Spanish, 1234, "1234"},
+		{language.Spanish, 12345, "12.345"},
+		{language.Hindi, 12345678, "1,23,45,678"},
+		{language.Japanese, 1234, "1,234"},
+	}
+	for _, tt := range tests {
+		if got := formatNumber(tt.lang, tt.num, 3); got != tt.want {
+			t.Errorf("formatNumber(%v, %v) = %q, want %q", tt.lang.Shortcut(), tt.num, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package messageformat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type parser struct {
+	src []rune
+	pos int
+	// plurals is the number of plurals enclosing the position, # is the plural value inside any of them
+	plurals int
+}
+
+/**
+* Parse parses an ICU MessageFormat pattern, the supported arguments are
+* {name}, {name, number[, integer|percent]},
+* {name, plural, [offset:n] =0 {...} one {...} other {...}} and {name, select, a {...} other {...}}
+**/
+func Parse(pattern string) (*Message, error) {
+	p := &parser{src: []rune(pattern)}
+	nodes, err := p.parseMessage()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected '}'")
+	}
+	return &Message{source: pattern, nodes: nodes}, nil
+}
+
+func MustParse(pattern string) *Message {
+	msg, err := Parse(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return msg
+}
+
+func (p *parser) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("Invalid message at offset %d: %v", p.pos, fmt.Sprintf(format, a...))
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() rune {
+	return p.src[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+// parseMessage reads until the end of input or an unmatched '}'
+func (p *parser) parseMessage() ([]node, error) {
+	inPlural := p.plurals > 0
+	var (
+		nodes []node
+		text  strings.Builder
+	)
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, textNode(text.String()))
+			text.Reset()
+		}
+	}
+	for !p.eof() {
+		r := p.peek()
+		switch {
+		case r == '\'':
+			p.parseQuoted(&text, inPlural)
+		case r == '{':
+			flush()
+			n, err := p.parseArgument()
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, n)
+		case r == '}':
+			flush()
+			return nodes, nil
+		case r == '#' && inPlural:
+			flush()
+			p.pos++
+			nodes = append(nodes, poundNode{})
+		default:
+			text.WriteRune(r)
+			p.pos++
+		}
+	}
+	flush()
+	return nodes, nil
+}
+
+/**
+* parseQuoted follows the ICU apostrophe rules:
+* '' is a literal apostrophe, an apostrophe before a syntax char starts a quoted literal,
+* any other apostrophe is a literal
+**/
+func (p *parser) parseQuoted(text *strings.Builder, inPlural bool) {
+	p.pos++
+	if p.eof() {
+		text.WriteRune('\'')
+		return
+	}
+	r := p.peek()
+	if r == '\'' {
+		text.WriteRune('\'')
+		p.pos++
+		return
+	}
+	if r != '{' && r != '}' && !(r == '#' && inPlural) {
+		text.WriteRune('\'')
+		return
+	}
+	for !p.eof() {
+		r = p.peek()
+		p.pos++
+		if r != '\'' {
+			text.WriteRune(r)
+			continue
+		}
+		if !p.eof() && p.peek() == '\'' {
+			text.WriteRune('\'')
+			p.pos++
+			continue
+		}
+		return
+	}
+}
+
+func (p *parser) parseIdentifier() string {
+	start := p.pos
+	for !p.eof() {
+		r := p.peek()
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			break
+		}
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+func (p *parser) expect(r rune) error {
+	p.skipSpace()
+	if p.eof() || p.peek() != r {
+		return p.errorf("expected '%c'", r)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) parseArgument() (node, error) {
+	// skip '{'
+	p.pos++
+	p.skipSpace()
+	name := p.parseIdentifier()
+	if name == "" {
+		return nil, p.errorf("missing argument name")
+	}
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("unclosed argument: %v", name)
+	}
+	if p.peek() == '}' {
+		p.pos++
+		return argNode{name: name}, nil
+	}
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	argType := p.parseIdentifier()
+	switch argType {
+	case "number":
+		return p.parseNumber(name)
+	case "plural":
+		return p.parsePlural(name)
+	case "select":
+		return p.parseSelect(name)
+	default:
+		return nil, p.errorf("unsupported argument type: %v", argType)
+	}
+}
+
+func (p *parser) parseNumber(name string) (node, error) {
+	p.skipSpace()
+	if !p.eof() && p.peek() == ',' {
+		p.pos++
+		p.skipSpace()
+		style := p.parseIdentifier()
+		if style != "integer" && style != "percent" {
+			return nil, p.errorf("unsupported number style: %v", style)
+		}
+		if err := p.expect('}'); err != nil {
+			return nil, err
+		}
+		return numberNode{name: name, style: style}, nil
+	}
+	if err := p.expect('}'); err != nil {
+		return nil, err
+	}
+	return numberNode{name: name}, nil
+}
+
+func (p *parser) parsePlural(name string) (node, error) {
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+	n := pluralNode{name: name}
+	p.skipSpace()
+	if strings.HasPrefix(string(p.src[p.pos:]), "offset:") {
+		p.pos += len("offset:")
+		p.skipSpace()
+		start := p.pos
+		for !p.eof() && unicode.IsDigit(p.peek()) {
+			p.pos++
+		}
+		offset, err := strconv.Atoi(string(p.src[start:p.pos]))
+		if err != nil {
+			return nil, p.errorf("invalid plural offset")
+		}
+		n.offset = offset
+	}
+	p.plurals++
+	cases, err := p.parseCases()
+	p.plurals--
+	if err != nil {
+		return nil, err
+	}
+	n.cases = cases
+	return n, nil
+}
+
+func (p *parser) parseSelect(name string) (node, error) {
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+	cases, err := p.parseCases()
+	if err != nil {
+		return nil, err
+	}
+	return selectNode{name: name, cases: cases}, nil
+}
+
+// parseCases reads "selector {message}" pairs up to the closing '}' of the argument
+func (p *parser) parseCases() (map[string][]node, error) {
+	cases := make(map[string][]node)
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorf("unclosed argument")
+		}
+		if p.peek() == '}' {
+			p.pos++
+			break
+		}
+		selector := ""
+		if p.peek() == '=' {
+			p.pos++
+			selector = "=" + p.parseIdentifier()
+		} else {
+			selector = p.parseIdentifier()
+		}
+		if selector == "" || selector == "=" {
+			return nil, p.errorf("missing selector")
+		}
+		if err := p.expect('{'); err != nil {
+			return nil, err
+		}
+		nodes, err := p.parseMessage()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect('}'); err != nil {
+			return nil, err
+		}
+		cases[selector] = nodes
+	}
+	if _, ok := cases["other"]; !ok {
+		return nil, p.errorf("missing 'other' case")
+	}
+	return cases, nil
+}
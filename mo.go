@@ -0,0 +1,199 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+const (
+	moMagic = 0x950412de
+	// size of the header, the hash table fields are included
+	moHeaderSize = 28
+)
+
+/**
+* moFormat reads compiled GNU gettext catalogs of both byte orders,
+* the hash table is not used since the whole catalog is loaded
+**/
+type moFormat struct{}
+
+func (moFormat) Extensions() []string {
+	return []string{"mo"}
+}
+
+// Compiled keeps the extractor from writing mo catalogs, they are compiled from another type
+func (moFormat) Compiled() bool {
+	return true
+}
+
+func (moFormat) Decode(data []byte, file FormatFile) (*I18nDict, error) {
+	entries, err := decodeMO(data)
+	if err != nil {
+		return nil, err
+	}
+	return entriesDict(entries, file)
+}
+
+func (moFormat) Encode(dict *I18nDict) ([]byte, error) {
+	return CompileMO(dict), nil
+}
+
+func decodeMO(data []byte) ([]*poEntry, error) {
+	if len(data) < moHeaderSize {
+		return nil, fmt.Errorf("Invalid mo file: too short")
+	}
+	var order binary.ByteOrder
+	switch {
+	case binary.LittleEndian.Uint32(data) == moMagic:
+		order = binary.LittleEndian
+	case binary.BigEndian.Uint32(data) == moMagic:
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("Invalid mo file: bad magic number")
+	}
+	// only the major revision 0 is defined
+	if revision := order.Uint32(data[4:]); revision>>16 != 0 {
+		return nil, fmt.Errorf("Invalid mo file: unsupported revision %v", revision)
+	}
+	count := int(order.Uint32(data[8:]))
+	originals := int(order.Uint32(data[12:]))
+	translations := int(order.Uint32(data[16:]))
+	// the tables must fit in the file before anything is allocated for count
+	for _, table := range []int{originals, translations} {
+		if table < 0 || count < 0 || count > (len(data)-table)/8 || table > len(data) {
+			return nil, fmt.Errorf("Invalid mo file: string table out of range")
+		}
+	}
+	str := func(table int, i int) (string, error) {
+		pos := table + i*8
+		if pos < 0 || pos+8 > len(data) {
+			return "", fmt.Errorf("Invalid mo file: string table out of range")
+		}
+		length := int(order.Uint32(data[pos:]))
+		offset := int(order.Uint32(data[pos+4:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return "", fmt.Errorf("Invalid mo file: string out of range")
+		}
+		return string(data[offset : offset+length]), nil
+	}
+	entries := make([]*poEntry, 0, count)
+	for i := 0; i < count; i++ {
+		original, err := str(originals, i)
+		if err != nil {
+			return nil, err
+		}
+		translation, err := str(translations, i)
+		if err != nil {
+			return nil, err
+		}
+		entry := &poEntry{}
+		if idx := strings.IndexByte(original, '\x04'); idx >= 0 {
+			entry.context, original = original[:idx], original[idx+1:]
+		}
+		if idx := strings.IndexByte(original, '\x00'); idx >= 0 {
+			entry.id, entry.idPlural = original[:idx], original[idx+1:]
+			entry.strs = strings.Split(translation, "\x00")
+		} else {
+			entry.id, entry.str = original, translation
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+/**
+* CompileMO compiles the dict to a little endian mo file without hash table,
+* fuzzy and untranslated keys are left out like msgfmt does
+**/
+func CompileMO(d *I18nDict) []byte {
+	type moString struct {
+		original    string
+		translation string
+	}
+	forms := gettextPluralOfHeader(d.PluralForms, language.GetLang(d.Lang))
+	header := strings.Join([]string{
+		"Language: " + d.Lang,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: 8bit",
+		"Plural-Forms: " + forms.forms,
+	}, "\n") + "\n"
+	strs := []moString{{"", header}}
+	// the keys read from gettext catalogs keep their msgctxt and msgid_plural
+	message := func(key string) GettextMessage {
+		msg, ok := d.Gettext[key]
+		if !ok {
+			return GettextMessage{ID: key, IDPlural: key}
+		}
+		if msg.IDPlural == "" {
+			msg.IDPlural = msg.ID
+		}
+		return msg
+	}
+	original := func(msg GettextMessage) string {
+		if msg.Context != "" {
+			return msg.Context + "\x04" + msg.ID
+		}
+		return msg.ID
+	}
+	for key, val := range d.Dict {
+		if _, ok := d.Plural[key]; ok || val == "" || d.IsFuzzy(key) {
+			continue
+		}
+		strs = append(strs, moString{original(message(key)), val})
+	}
+	for key, p := range d.Plural {
+		translated := forms.fromPlural(p)
+		if d.IsFuzzy(key) || strings.Join(translated, "") == "" {
+			continue
+		}
+		msg := message(key)
+		strs = append(strs, moString{original(msg) + "\x00" + msg.IDPlural, strings.Join(translated, "\x00")})
+	}
+	// the originals are sorted for binary search
+	sort.Slice(strs, func(i, j int) bool {
+		return strs[i].original < strs[j].original
+	})
+
+	var buf bytes.Buffer
+	order := binary.LittleEndian
+	write := func(v int) {
+		binary.Write(&buf, order, uint32(v))
+	}
+	originals := moHeaderSize
+	translations := originals + len(strs)*8
+	offset := translations + len(strs)*8
+	write(moMagic)
+	write(0)
+	write(len(strs))
+	write(originals)
+	write(translations)
+	// no hash table
+	write(0)
+	write(offset)
+	// the strings are nul terminated, the terminator is not counted in the length
+	for _, s := range strs {
+		write(len(s.original))
+		write(offset)
+		offset += len(s.original) + 1
+	}
+	for _, s := range strs {
+		write(len(s.translation))
+		write(offset)
+		offset += len(s.translation) + 1
+	}
+	for _, s := range strs {
+		buf.WriteString(s.original)
+		buf.WriteByte(0)
+	}
+	for _, s := range strs {
+		buf.WriteString(s.translation)
+		buf.WriteByte(0)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,90 @@
+package i18n
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+func TestMORoundTrip(t *testing.T) {
+	d := &I18nDict{
+		Lang: "pl",
+		Dict: dict{
+			"hello":     "Cześć",
+			"menu.open": "Otwórz",
+			"draft":     "Szkic",
+			"missing":   "",
+		},
+		Plural: pluralDict{
+			"%d file": {language.PluralOne: "%d plik", language.PluralFew: "%d pliki", language.PluralMany: "%d plików"},
+		},
+		Flags: map[string][]string{"draft": {"fuzzy"}},
+		Gettext: map[string]GettextMessage{
+			"menu.open": {Context: "menu", ID: "open"},
+			"%d file":   {ID: "%d file", IDPlural: "%d files"},
+		},
+	}
+	got := roundTrip(t, moFormat{}, d, FormatFile{Namespace: "pl.app", Splitter: "."})
+	// fuzzy and untranslated keys are not compiled, the last form also serves other
+	assertDict(t, got, &I18nDict{
+		Lang:      "pl",
+		Namespace: "pl.app",
+		Dict:      dict{"hello": "Cześć", "menu.open": "Otwórz"},
+		Plural: pluralDict{
+			"%d file": {language.PluralOne: "%d plik", language.PluralFew: "%d pliki", language.PluralMany: "%d plików", language.PluralOther: "%d plików"},
+		},
+	})
+	if !reflect.DeepEqual(got.Gettext, d.Gettext) {
+		t.Errorf("Gettext = %v, want %v", got.Gettext, d.Gettext)
+	}
+}
+
+// bigEndian rewrites the header and the string tables of a little endian mo file
+func bigEndian(data []byte) []byte {
+	res := append([]byte(nil), data...)
+	count := int(binary.LittleEndian.Uint32(data[8:]))
+	for pos := 0; pos < moHeaderSize+count*16; pos += 4 {
+		binary.BigEndian.PutUint32(res[pos:], binary.LittleEndian.Uint32(data[pos:]))
+	}
+	return res
+}
+
+func TestMODecodeBigEndian(t *testing.T) {
+	data := bigEndian(CompileMO(&I18nDict{Lang: "en", Dict: dict{"hello": "Hello"}}))
+	got, err := moFormat{}.Decode(data, FormatFile{Splitter: "."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Lang != "en" || got.Dict["hello"] != "Hello" {
+		t.Errorf("Decode() = %v, %v, want en, map[hello:Hello]", got.Lang, got.Dict)
+	}
+}
+
+func TestMODecodeInvalid(t *testing.T) {
+	valid := CompileMO(&I18nDict{Lang: "en", Dict: dict{"hello": "Hello"}})
+	patch := func(pos int, v uint32) []byte {
+		data := append([]byte(nil), valid...)
+		binary.LittleEndian.PutUint32(data[pos:], v)
+		return data
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"short", valid[:moHeaderSize-1]},
+		{"magic", patch(0, 0xdeadbeef)},
+		{"revision", patch(4, 1<<16)},
+		{"count", patch(8, 1<<30)},
+		{"originals", patch(12, uint32(len(valid)))},
+		{"translations", patch(16, 1<<31)},
+		{"string offset", patch(moHeaderSize+12, uint32(len(valid)))},
+		{"string length", patch(moHeaderSize, 1<<31)},
+	}
+	for _, tt := range tests {
+		if _, err := (moFormat{}).Decode(tt.data, FormatFile{Splitter: "."}); err == nil {
+			t.Errorf("Decode(%v) succeeded", tt.name)
+		}
+	}
+}
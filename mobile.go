@@ -0,0 +1,236 @@
+package i18n
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+const (
+	Android = "android"
+	IOS     = "ios"
+)
+
+// mobileTable returns the file name of a namespace, the default namespace uses the platform default
+func mobileTable(platform string, namespace string) string {
+	if namespace != "index" {
+		return namespace
+	}
+	if platform == Android {
+		return "strings"
+	}
+	return appleTable
+}
+
+func mobileNamespace(platform string, table string) string {
+	if (platform == Android && table == "strings") || (platform == IOS && table == appleTable) {
+		return "index"
+	}
+	return table
+}
+
+// namespaceKeys lists the keys of a namespace in every language
+func namespaceKeys(opts *I18nOpts, catalogs Catalogs, namespace string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, lang := range opts.langs {
+		d, ok := catalogs[lang][namespace]
+		if !ok {
+			continue
+		}
+		for key := range d.Dict {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		for key := range d.Plural {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+/**
+* mobileDict drops the untranslated keys so the apps fall back to their default language,
+* the keys are the translations of the source language when it is untranslated
+**/
+func mobileDict(opts *I18nOpts, lang language.I18nLang, d *I18nDict) *I18nDict {
+	nd := d.Clone()
+	isSrc := lang == opts.src
+	for key, val := range nd.Dict {
+		if val == "" && isSrc {
+			nd.Dict[key] = key
+		} else if val == "" {
+			delete(nd.Dict, key)
+		}
+	}
+	for key, forms := range nd.Plural {
+		for category, form := range forms {
+			if form == "" && isSrc {
+				forms[category] = key
+			} else if form == "" {
+				delete(forms, category)
+			}
+		}
+		if len(forms) == 0 {
+			delete(nd.Plural, key)
+		}
+	}
+	return nd
+}
+
+// mobileExchange is the exchange format of the android or the ios resources
+type mobileExchange struct {
+	platform string
+}
+
+/**
+* Export writes the catalogs for the mobile apps into dir,
+* android: values[-qualifier]/<namespace>.xml with the source language as default,
+* ios: <lang>.lproj/<namespace>.strings and .stringsdict, the default namespace is strings.xml or Localizable
+**/
+func (m mobileExchange) Export(opts *I18nOpts, catalogs Catalogs, dir string) error {
+	for _, lang := range opts.langs {
+		for namespace, d := range catalogs[lang] {
+			d = mobileDict(opts, lang, d)
+			table := mobileTable(m.platform, namespace)
+			switch m.platform {
+			case Android:
+				values := "values"
+				if lang != opts.src {
+					values += "-" + androidQualifier(lang)
+				}
+				data, err := EncodeAndroid(d, androidNames(namespaceKeys(opts, catalogs, namespace)))
+				if err != nil {
+					return err
+				}
+				if err := writeExchangeFile(filepath.Join(dir, values, table+".xml"), data); err != nil {
+					return err
+				}
+			case IOS:
+				lproj := filepath.Join(dir, lang.Shortcut()+".lproj")
+				if err := writeExchangeFile(filepath.Join(lproj, table+".strings"), EncodeStrings(d)); err != nil {
+					return err
+				}
+				if len(d.Plural) == 0 {
+					continue
+				}
+				if err := writeExchangeFile(filepath.Join(lproj, table+".stringsdict"), EncodeStringsdict(d)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+/**
+* Import reads the translations exported by Export back,
+* the android resource names are mapped back to the keys of the catalogs,
+* directories of the other qualifiers and languages not enabled are skipped
+**/
+func (m mobileExchange) Import(opts *I18nOpts, catalogs Catalogs, dir string) (Catalogs, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	res := make(Catalogs)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		var shortcut string
+		switch {
+		case m.platform == Android && entry.Name() == "values":
+			shortcut = opts.src.Shortcut()
+		case m.platform == Android && strings.HasPrefix(entry.Name(), "values-"):
+			shortcut = androidLang(strings.TrimPrefix(entry.Name(), "values-"))
+		case m.platform == IOS && entry.Name() == "Base.lproj":
+			shortcut = opts.src.Shortcut()
+		case m.platform == IOS && strings.HasSuffix(entry.Name(), ".lproj"):
+			shortcut = strings.TrimSuffix(entry.Name(), ".lproj")
+		}
+		if !opts.IsEnabled(shortcut) {
+			continue
+		}
+		lang := language.GetLang(shortcut)
+		files, err := ioutil.ReadDir(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			ext := filepath.Ext(file.Name())
+			table := strings.TrimSuffix(file.Name(), ext)
+			data, err := ioutil.ReadFile(filepath.Join(dir, entry.Name(), file.Name()))
+			if err != nil {
+				return nil, err
+			}
+			var d *I18nDict
+			switch {
+			case m.platform == Android && ext == ".xml":
+				d, err = DecodeAndroid(data)
+			case m.platform == IOS && ext == ".strings":
+				d, err = DecodeStrings(data)
+			case m.platform == IOS && ext == ".stringsdict":
+				d, err = DecodeStringsdict(data)
+			default:
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("Failed to import %v, error: %v", filepath.Join(entry.Name(), file.Name()), err)
+			}
+			namespace := mobileNamespace(m.platform, table)
+			if m.platform == Android {
+				d = androidKeys(namespaceKeys(opts, catalogs, namespace), d)
+			}
+			if _, ok := res[lang]; !ok {
+				res[lang] = make(map[string]*I18nDict)
+			}
+			// the strings and the stringsdict of a table share the namespace
+			if od, ok := res[lang][namespace]; ok {
+				d.Lang = od.Lang
+				od.Overwrite(d)
+			} else {
+				d.Lang = lang.Shortcut()
+				res[lang][namespace] = d
+			}
+		}
+	}
+	return res, nil
+}
+
+// androidKeys renames the resources to the keys of the namespace, unknown resources keep their name
+func androidKeys(nsKeys []string, d *I18nDict) *I18nDict {
+	keys := make(map[string]string)
+	for key, name := range androidNames(nsKeys) {
+		keys[name] = key
+	}
+	rename := func(name string) string {
+		if key, ok := keys[name]; ok {
+			return key
+		}
+		return name
+	}
+	nd := &I18nDict{
+		Dict:     make(dict, len(d.Dict)),
+		Plural:   make(pluralDict, len(d.Plural)),
+		Comments: make(map[string]string, len(d.Comments)),
+	}
+	for name, val := range d.Dict {
+		nd.Dict[rename(name)] = val
+	}
+	for name, forms := range d.Plural {
+		nd.Plural[rename(name)] = forms
+	}
+	for name, comment := range d.Comments {
+		nd.Comments[rename(name)] = comment
+	}
+	return nd
+}
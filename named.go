@@ -0,0 +1,270 @@
+package i18n
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+// matches {{.Name}} and {name} at the start of the input
+var placeholderRegexp = regexp.MustCompile(`^(?:\{\{\s*\.(\w+)\s*\}\}|\{(\w+)\})`)
+
+// matches the start of an ICU argument with a type, e.g. {count, plural,
+var argumentRegexp = regexp.MustCompile(`^\{\s*(\w+)\s*,`)
+
+/**
+* namedParams converts a map with string keys or a struct into named parameters,
+* struct fields are named by their `i18n` tag, and by their field name as well
+**/
+func namedParams(params interface{}) (map[string]interface{}, error) {
+	if m, ok := params.(map[string]interface{}); ok {
+		return m, nil
+	}
+	res := make(map[string]interface{})
+	if params == nil {
+		return res, nil
+	}
+	val := reflect.ValueOf(params)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return res, nil
+		}
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("Invalid named params, map key must be string: %T", params)
+		}
+		iter := val.MapRange()
+		for iter.Next() {
+			res[iter.Key().String()] = iter.Value().Interface()
+		}
+	case reflect.Struct:
+		typ := val.Type()
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.PkgPath != "" {
+				// unexported field
+				continue
+			}
+			tag := field.Tag.Get("i18n")
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				res[tag] = val.Field(i).Interface()
+			}
+			if _, ok := res[field.Name]; !ok {
+				res[field.Name] = val.Field(i).Interface()
+			}
+		}
+	default:
+		return nil, fmt.Errorf("Invalid named params: %T", params)
+	}
+	return res, nil
+}
+
+// placeholderToken is a placeholder of a translation at val[start:end]
+type placeholderToken struct {
+	name       string
+	start, end int
+	// typed is set for the ICU arguments with a type like {n, plural, ...}, TransNamed keeps them as is
+	typed bool
+}
+
+/**
+* scanPlaceholders is the tokenizer shared by TransNamed and the placeholder validation,
+* it finds {name}, {{.Name}} and the ICU arguments with a type whose cases are scanned as nested translations,
+* apostrophes are literal unlike in ICU messages, so l'{object} uses the placeholder object
+**/
+func scanPlaceholders(val string) []placeholderToken {
+	var tokens []placeholderToken
+	scanPlaceholdersAt(val, 0, &tokens)
+	return tokens
+}
+
+func scanPlaceholdersAt(val string, offset int, tokens *[]placeholderToken) {
+	for i := 0; i < len(val); i++ {
+		if val[i] != '{' {
+			continue
+		}
+		if match := placeholderRegexp.FindStringSubmatch(val[i:]); match != nil {
+			name := match[1]
+			if name == "" {
+				name = match[2]
+			}
+			*tokens = append(*tokens, placeholderToken{name: name, start: offset + i, end: offset + i + len(match[0])})
+			i += len(match[0]) - 1
+			continue
+		}
+		loc := argumentRegexp.FindStringSubmatchIndex(val[i:])
+		if loc == nil {
+			continue
+		}
+		typed := len(*tokens)
+		*tokens = append(*tokens, placeholderToken{name: val[i+loc[2] : i+loc[3]], start: offset + i, typed: true})
+		// the cases are the blocks at depth 1, an unclosed argument takes the rest of val
+		depth, caseStart, end := 1, 0, len(val)
+		for j := i + loc[1]; j < len(val) && end == len(val); j++ {
+			switch val[j] {
+			case '{':
+				depth++
+				if depth == 2 {
+					caseStart = j + 1
+				}
+			case '}':
+				depth--
+				if depth == 1 {
+					scanPlaceholdersAt(val[caseStart:j], offset+caseStart, tokens)
+				} else if depth == 0 {
+					end = j + 1
+				}
+			}
+		}
+		(*tokens)[typed].end = offset + end
+		i = end - 1
+	}
+}
+
+// replaceNamed replaces the placeholders, unknown placeholders are kept as is
+func replaceNamed(val string, params map[string]interface{}) (string, error) {
+	var (
+		res     strings.Builder
+		missing []string
+		last    int
+	)
+	for _, token := range scanPlaceholders(val) {
+		if token.typed {
+			continue
+		}
+		param, ok := params[token.name]
+		if !ok {
+			missing = append(missing, token.name)
+			continue
+		}
+		res.WriteString(val[last:token.start])
+		res.WriteString(fmt.Sprint(param))
+		last = token.end
+	}
+	res.WriteString(val[last:])
+	if len(missing) > 0 {
+		return res.String(), fmt.Errorf("Missing named params: %v", strings.Join(missing, ", "))
+	}
+	return res.String(), nil
+}
+
+// placeholders returns the sorted placeholder set of a translation, ICU arguments with a type count by their name
+func placeholders(val string) []string {
+	set := make(map[string]bool)
+	for _, token := range scanPlaceholders(val) {
+		set[token.name] = true
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func samePlaceholders(expected []string, actual []string) bool {
+	return strings.Join(expected, ",") == strings.Join(actual, ",")
+}
+
+// unknownPlaceholders returns the placeholders of actual missing in expected
+func unknownPlaceholders(expected []string, actual []string) []string {
+	known := make(map[string]bool)
+	for _, name := range expected {
+		known[name] = true
+	}
+	var unknown []string
+	for _, name := range actual {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
+
+/**
+* validatePlaceholders checks that every language uses the same placeholders as the source language,
+* the other form of a plural must use the placeholders of the source other form, or of the source string,
+* the remaining forms may drop placeholders, e.g. "one item", but must not use unknown ones
+**/
+func (c *catalog) validatePlaceholders(src language.I18nLang) []error {
+	var errs []error
+	mismatched := func(lang language.I18nLang, namespace string, key string, expected []string, actual []string) {
+		errs = append(errs, fmt.Errorf("Placeholders mismatched, lang: %v, namespace: %v, key: %v, expected: %v, actual: %v",
+			lang.Shortcut(), namespace, key, expected, actual))
+	}
+	compare := func(src dict, lang language.I18nLang, target dict, namespace string) {
+		for key, val := range target {
+			srcVal, ok := src[key]
+			if !ok || val == "" {
+				continue
+			}
+			if expected, actual := placeholders(srcVal), placeholders(val); !samePlaceholders(expected, actual) {
+				mismatched(lang, namespace, key, expected, actual)
+			}
+		}
+	}
+	comparePlurals := func(src dict, srcPlurals pluralDict, lang language.I18nLang, target pluralDict, namespace string) {
+		for key, forms := range target {
+			var expected []string
+			if srcForms, ok := srcPlurals[key]; ok && srcForms[language.PluralOther] != "" {
+				expected = placeholders(srcForms[language.PluralOther])
+			} else if srcVal, ok := src[key]; ok && srcVal != "" {
+				expected = placeholders(srcVal)
+			} else {
+				continue
+			}
+			for category, val := range forms {
+				if val == "" {
+					continue
+				}
+				actual := placeholders(val)
+				if category == language.PluralOther && !samePlaceholders(expected, actual) ||
+					len(unknownPlaceholders(expected, actual)) > 0 {
+					mismatched(lang, namespace, key+"."+string(category), expected, actual)
+				}
+			}
+		}
+	}
+	for lang, target := range c.dicts {
+		if lang != src {
+			compare(c.dicts[src], lang, target, "")
+		}
+	}
+	for lang, target := range c.plurals {
+		if lang != src {
+			comparePlurals(c.dicts[src], c.plurals[src], lang, target, "")
+		}
+	}
+	// namespaces are prefixed with the language
+	srcNamespace := func(lang language.I18nLang, namespace string) string {
+		return src.Shortcut() + strings.TrimPrefix(namespace, lang.Shortcut())
+	}
+	for lang, dicts := range c.dictsWithNamespace {
+		if lang == src {
+			continue
+		}
+		for namespace, target := range dicts {
+			compare(c.dictsWithNamespace[src][srcNamespace(lang, namespace)], lang, target, namespace)
+		}
+	}
+	for lang, plurals := range c.pluralsWithNamespace {
+		if lang == src {
+			continue
+		}
+		for namespace, target := range plurals {
+			ns := srcNamespace(lang, namespace)
+			comparePlurals(c.dictsWithNamespace[src][ns], c.pluralsWithNamespace[src][ns], lang, target, namespace)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,117 @@
+package i18n
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func writeTestFile(t *testing.T, dir string, name string, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplaceNamed(t *testing.T) {
+	params := map[string]interface{}{"name": "Ann", "n": 3, "object": "ami"}
+	tests := []struct {
+		val     string
+		want    string
+		missing bool
+	}{
+		{"Hello {name}", "Hello Ann", false},
+		{"Hello {{.name}}, {{ .n }}", "Hello Ann, 3", false},
+		{"l'{object} de {name}", "l'ami de Ann", false},
+		{"{name} has {missing}", "Ann has {missing}", true},
+		{"{n, plural, one {# item} other {{name} has # items}}", "{n, plural, one {# item} other {Ann has # items}}", false},
+		{"{ name }", "{ name }", false},
+	}
+	for _, tt := range tests {
+		got, err := replaceNamed(tt.val, params)
+		if got != tt.want || (err != nil) != tt.missing {
+			t.Errorf("replaceNamed(%q) = %q, %v, want %q", tt.val, got, err, tt.want)
+		}
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	tests := []struct {
+		val  string
+		want []string
+	}{
+		{"Hello", []string{}},
+		{"{b} and {{.a}} and {b}", []string{"a", "b"}},
+		// apostrophes are literal, as in TransNamed
+		{"l'{object}", []string{"object"}},
+		{"{n, number} {total}", []string{"n", "total"}},
+		{"{n, plural, =0 {none} one {# item} other {{owner} has # items}}", []string{"n", "owner"}},
+		{"{g, select, female {{n, plural, other {# {x}}}} other {}}", []string{"g", "n", "x"}},
+		{"{n, plural, other {unclosed", []string{"n"}},
+	}
+	for _, tt := range tests {
+		if got := placeholders(tt.val); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("placeholders(%q) = %v, want %v", tt.val, got, tt.want)
+		}
+	}
+}
+
+func TestNamedParams(t *testing.T) {
+	type user struct {
+		Name    string `i18n:"name"`
+		Age     int
+		Ignored string `i18n:"-"`
+		secret  string
+	}
+	got, err := namedParams(&user{Name: "Ann", Age: 3, Ignored: "x", secret: "y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"name": "Ann", "Name": "Ann", "Age": 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("namedParams(struct) = %v, want %v", got, want)
+	}
+	if _, err := namedParams(map[int]string{1: "a"}); err == nil {
+		t.Error("namedParams(map[int]string) succeeded")
+	}
+	if _, err := namedParams(42); err == nil {
+		t.Error("namedParams(int) succeeded")
+	}
+}
+
+func TestStrictPlaceholders(t *testing.T) {
+	tests := []struct {
+		name  string
+		ko    string
+		valid bool
+	}{
+		{"same placeholders", `"hello":"{name} 안녕"`, true},
+		{"apostrophe", `"hello":"l'{name}"`, true},
+		{"missing placeholder", `"hello":"안녕"`, false},
+		{"unknown placeholder", `"hello":"{user} 안녕"`, false},
+		{"plural other form", `"files":{"other":"{owner}: %d"}`, true},
+		{"plural other without placeholder", `"files":{"other":"%d"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			en := `{"language":"en","dict":{"hello":"Hello {name}","files":{"one":"one file","other":"{owner}: %d files"}}}`
+			writeTestFile(t, dir, "en.json", en)
+			writeTestFile(t, dir, "ko.json", `{"language":"ko","dict":{`+tt.ko+`}}`)
+			opts := NewI18nOpts()
+			opts.SetEnableLangs("en,ko")
+			opts.SetSrcLang("en")
+			opts.SetLanguageDir(dir)
+			opts.SetStrictPlaceholders(true)
+			log := logrus.New()
+			log.SetOutput(ioutil.Discard)
+			err := NewBundle(opts, log).Load()
+			if (err == nil) != tt.valid {
+				t.Errorf("Load() error = %v, want valid: %v", err, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+/**
+* NamespaceHandle translates in the namespace of a Go package,
+* the namespace comes from the import path instead of runtime.Caller,
+* so it does not depend on the working directory or on -trimpath
+**/
+type NamespaceHandle struct {
+	importPath string
+	// nil for the default bundle, resolved on every call
+	bundle *Bundle
+	// reports once that the import path is outside of the module
+	warnOnce sync.Once
+}
+
+/**
+* Namespace returns a handle on the default bundle, it is meant to be created once per package:
+* var tr = i18n.Namespace("github.com/org/app/billing")
+* the handle can be created before Init, the extractor generates it with -gen
+**/
+func Namespace(importPath string) *NamespaceHandle {
+	return &NamespaceHandle{importPath: importPath}
+}
+
+// Namespace returns a handle on the bundle
+func (b *Bundle) Namespace(importPath string) *NamespaceHandle {
+	return &NamespaceHandle{importPath: importPath, bundle: b}
+}
+
+func (n *NamespaceHandle) getBundle() *Bundle {
+	if n.bundle != nil {
+		return n.bundle
+	}
+	b := DefaultBundle()
+	if b == nil {
+		panic("i18n is not initialized.")
+	}
+	return b
+}
+
+var (
+	buildModuleOnce sync.Once
+	buildModule     string
+)
+
+// buildModulePath returns the path of the main module read from the build info, empty if unavailable
+func buildModulePath() string {
+	buildModuleOnce.Do(func() {
+		if info, ok := debug.ReadBuildInfo(); ok && info.Main.Path != "command-line-arguments" {
+			buildModule = info.Main.Path
+		}
+	})
+	return buildModule
+}
+
+// ModulePath returns the module path set by SetModulePath, the main module of the binary otherwise
+func (opts *I18nOpts) ModulePath() string {
+	if opts.modulePath != "" {
+		return opts.modulePath
+	}
+	return buildModulePath()
+}
+
+/**
+* ImportNamespace converts an import path into a namespace, without the language prefix,
+* e.g. github.com/org/app/billing/invoice -> billing.invoice with module github.com/org/app,
+* an import path outside of the module is converted as a whole
+**/
+func (opts *I18nOpts) ImportNamespace(importPath string) string {
+	ns, _ := opts.importNamespace(importPath)
+	return ns
+}
+
+// importNamespace reports whether the import path is inside the module
+func (opts *I18nOpts) importNamespace(importPath string) (string, bool) {
+	modulePath := opts.ModulePath()
+	if modulePath == "" {
+		return convert(importPath, opts.splitter), false
+	}
+	if importPath == modulePath {
+		return "", true
+	}
+	if !strings.HasPrefix(importPath, modulePath+"/") {
+		return convert(importPath, opts.splitter), false
+	}
+	return convert(strings.TrimPrefix(importPath, modulePath+"/"), opts.splitter), true
+}
+
+/**
+* namespace is empty if namespace mode is disabled,
+* an import path outside of the module is reported once since its keys are not where the extractor put them
+**/
+func (n *NamespaceHandle) namespace(b *Bundle) string {
+	if !b.opts.enableNamespace {
+		return ""
+	}
+	ns, ok := b.opts.importNamespace(n.importPath)
+	if !ok {
+		n.warnOnce.Do(func() {
+			b.log.Errorf("Failed to resolve namespace, import path: %v is outside of module: %q, set it with SetModulePath", n.importPath, b.opts.ModulePath())
+		})
+	}
+	return ns
+}
+
+func (n *NamespaceHandle) ImportPath() string {
+	return n.importPath
+}
+
+func (n *NamespaceHandle) Trans(key string) string {
+	b := n.getBundle()
+	return b.translate(nil, b.opts.GetTargetLang(), key, n.namespace(b))
+}
+
+func (n *NamespaceHandle) Transf(key string, a ...interface{}) string {
+	b := n.getBundle()
+	return fmt.Sprintf(b.translate(nil, b.opts.GetTargetLang(), key, n.namespace(b)), a...)
+}
+
+func (n *NamespaceHandle) TransCtx(ctx context.Context, key string) string {
+	b := n.getBundle()
+	tenant, lang := b.ctxScope(ctx)
+	return b.translate(tenant, lang, key, n.namespace(b))
+}
+
+func (n *NamespaceHandle) TransfCtx(ctx context.Context, key string, a ...interface{}) string {
+	b := n.getBundle()
+	tenant, lang := b.ctxScope(ctx)
+	return fmt.Sprintf(b.translate(tenant, lang, key, n.namespace(b)), a...)
+}
+
+func (n *NamespaceHandle) TransPluralCtx(ctx context.Context, key string, count interface{}, a ...interface{}) string {
+	b := n.getBundle()
+	tenant, lang := b.ctxScope(ctx)
+	return fmt.Sprintf(b.translatePlural(tenant, lang, key, count, n.namespace(b)), a...)
+}
+
+func (n *NamespaceHandle) TransMsgCtx(ctx context.Context, key string, args map[string]interface{}) string {
+	b := n.getBundle()
+	tenant, lang := b.ctxScope(ctx)
+	return b.translateMsg(tenant, lang, key, args, n.namespace(b))
+}
+
+func (n *NamespaceHandle) TransNamedCtx(ctx context.Context, key string, params interface{}) string {
+	b := n.getBundle()
+	tenant, lang := b.ctxScope(ctx)
+	return b.translateNamed(tenant, lang, key, params, n.namespace(b))
+}
+
+func (n *NamespaceHandle) TransPlural(key string, count interface{}, a ...interface{}) string {
+	b := n.getBundle()
+	return fmt.Sprintf(b.translatePlural(nil, b.opts.GetTargetLang(), key, count, n.namespace(b)), a...)
+}
+
+func (n *NamespaceHandle) TransMsg(key string, args map[string]interface{}) string {
+	b := n.getBundle()
+	return b.translateMsg(nil, b.opts.GetTargetLang(), key, args, n.namespace(b))
+}
+
+func (n *NamespaceHandle) TransNamed(key string, params interface{}) string {
+	b := n.getBundle()
+	return b.translateNamed(nil, b.opts.GetTargetLang(), key, params, n.namespace(b))
+}
@@ -2,44 +2,97 @@ package i18n
 
 import (
 	"encoding/json"
-	"io/ioutil"
-	"os"
+	"io/fs"
+	"strings"
 )
 
-func ParserFactory(opts *I18nOpts) I18nParser {
-	switch opts.fileType {
-	case "json":
-		return NewJsonParser(opts)
-	default:
-		return NewJsonParser(opts)
+// ParserFactory returns the parser of the file type of opts, the file type must be registered
+func ParserFactory(opts *I18nOpts) (I18nParser, error) {
+	format, err := LookupFormat(opts.fileType)
+	if err != nil {
+		return nil, err
 	}
+	return &formatParser{opts, format}, nil
 }
 
-func NewJsonParser(opts *I18nOpts) *JsonParser {
-	return &JsonParser{opts}
-}
-
-type JsonParser struct {
-	opts *I18nOpts
+// formatParser reads the files with a registered format
+type formatParser struct {
+	opts   *I18nOpts
+	format Format
 }
 
-func (jp *JsonParser) parse(fpath string) (*I18nDict, error) {
-	var (
-		err  error
-		dict I18nDict
-	)
-	fp, err := os.Open(fpath)
+func (fp *formatParser) parse(fsys fs.FS, root string, fpath string) (*I18nDict, error) {
+	data, err := fs.ReadFile(fsys, fpath)
 	if err != nil {
 		return nil, err
 	}
-	defer fp.Close()
-	bytes, err := ioutil.ReadAll(fp)
+	namespace := fileNamespace(fpath, root, fp.opts.fileType, fp.opts.splitter)
+	file := FormatFile{
+		Path:     fpath,
+		Lang:     strings.SplitN(namespace, fp.opts.splitter, 2)[0],
+		Splitter: fp.opts.splitter,
+	}
+	if fp.opts.enableNamespace {
+		file.Namespace = namespace
+	}
+	dict, err := fp.format.Decode(data, file)
 	if err != nil {
 		return nil, err
 	}
-	err = json.Unmarshal((bytes), &dict)
-	if err != nil {
+	if dict.Lang == "" {
+		dict.Lang = file.Lang
+	}
+	return dict, nil
+}
+
+/**
+* jsonFormat reads the "dict" object flat or nested like the i18next and vue-i18n catalogs,
+* nested objects are flattened with the splitter and an object holding only plural categories,
+* "other" among them, is a plural entry, the namespace falls back to the one derived from the path,
+* the keys are written nested when the format is returned by Nested
+**/
+type jsonFormat struct {
+	nested   bool
+	splitter string
+}
+
+type jsonDict struct {
+	Lang      string                 `json:"language"`
+	Namespace string                 `json:"namespace,omitempty"`
+	Dict      map[string]interface{} `json:"dict"`
+}
+
+func (jsonFormat) Extensions() []string {
+	return []string{"json"}
+}
+
+func (jsonFormat) Decode(data []byte, file FormatFile) (*I18nDict, error) {
+	var raw jsonDict
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	d := &I18nDict{
+		Lang:      raw.Lang,
+		Namespace: raw.Namespace,
+		Dict:      make(dict),
+		Plural:    make(pluralDict),
+	}
+	if d.Namespace == "" {
+		d.Namespace = file.Namespace
+	}
+	if err := flattenMap(raw.Dict, "", file.Splitter, d); err != nil {
 		return nil, err
 	}
-	return &dict, nil
+	return d, nil
+}
+
+func (jf jsonFormat) Encode(dict *I18nDict) ([]byte, error) {
+	if !jf.nested {
+		return json.MarshalIndent(dict, "", "    ")
+	}
+	return json.MarshalIndent(jsonDict{dict.Lang, dict.Namespace, nestMap(dict, jf.splitter)}, "", "    ")
+}
+
+func (jsonFormat) Nested(splitter string) Format {
+	return jsonFormat{nested: true, splitter: splitter}
 }
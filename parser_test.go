@@ -0,0 +1,118 @@
+package i18n
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	d := &I18nDict{
+		Lang:      "en",
+		Namespace: "en.app",
+		Dict: dict{
+			"title":          "Files",
+			"menu.open":      "Open",
+			"menu.save.all":  "Save all",
+			"menu":           "Menu",
+			"size.one":       "plain one",
+			"size.other":     "plain other",
+			"quoted":         `say "hi" <b>`,
+			"sub.":           "trailing splitter",
+			"sizes.small.xs": "extra small",
+		},
+		Plural: pluralDict{
+			"menu.files": {language.PluralOne: "%d file", language.PluralOther: "%d files"},
+		},
+	}
+	for _, splitter := range []string{".", "_"} {
+		formats := []Format{jsonFormat{}, jsonFormat{}.Nested(splitter)}
+		for _, format := range formats {
+			want := d
+			if splitter != "." {
+				want = d.Clone()
+				want.Dict = make(dict)
+				for key, val := range d.Dict {
+					want.Dict[strings.ReplaceAll(key, ".", splitter)] = val
+				}
+				want.Plural = pluralDict{"menu" + splitter + "files": d.Plural["menu.files"]}
+			}
+			got := roundTrip(t, format, want, FormatFile{Splitter: splitter})
+			assertDict(t, got, want)
+		}
+	}
+}
+
+func TestJSONNested(t *testing.T) {
+	d := &I18nDict{
+		Lang: "en",
+		Dict: dict{"menu.open": "Open", "menu.save": "Save", "x.one": "a", "x.other": "b"},
+		Plural: pluralDict{
+			"files": {language.PluralOne: "%d file", language.PluralOther: "%d files"},
+		},
+	}
+	data, err := jsonFormat{}.Nested(".").Encode(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Dict map[string]interface{} `json:"dict"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if menu, ok := raw.Dict["menu"].(map[string]interface{}); !ok || menu["open"] != "Open" {
+		t.Errorf("menu is not nested: %s", data)
+	}
+	// plain keys named after the plural categories stay flat, they would be read as a plural entry
+	if raw.Dict["x.one"] != "a" || raw.Dict["x.other"] != "b" {
+		t.Errorf("x.one and x.other are not flat: %s", data)
+	}
+}
+
+func TestJSONDecode(t *testing.T) {
+	data := []byte(`{"dict":{"a":{"b":"ab","c":{"one":"1","other":"n"}},"d":{"one":"1","few":"2"}}}`)
+	got, err := jsonFormat{}.Decode(data, FormatFile{Namespace: "en.app", Splitter: "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDict(t, got, &I18nDict{
+		Namespace: "en.app",
+		Dict:      dict{"a/b": "ab", "d/one": "1", "d/few": "2"},
+		Plural:    pluralDict{"a/c": {language.PluralOne: "1", language.PluralOther: "n"}},
+	})
+	for _, data := range []string{`{"dict":{"a":1}}`, `{"dict":{"a":["b"]}}`, `{"dict":`} {
+		if _, err := (jsonFormat{}).Decode([]byte(data), FormatFile{Splitter: "."}); err == nil {
+			t.Errorf("Decode(%v) succeeded", data)
+		}
+	}
+}
+
+func TestI18nDictJSON(t *testing.T) {
+	d := &I18nDict{
+		Lang: "en",
+		Dict: dict{"title": "Files"},
+		Plural: pluralDict{
+			"files": {language.PluralOther: "%d files", language.PluralOne: "%d file"},
+		},
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the categories keep the CLDR order
+	if want := `{"language":"en","dict":{"files":{"one":"%d file","other":"%d files"},"title":"Files"}}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+	var got I18nDict
+	if err := json.Unmarshal([]byte(`{"language":"en","dict":{"files":{"one":"%d file","other":"%d files"},"menu":{"title":"Files"}}}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	assertDict(t, &got, &I18nDict{
+		Lang:   "en",
+		Dict:   dict{"menu.title": "Files"},
+		Plural: d.Plural,
+	})
+}
@@ -0,0 +1,381 @@
+package i18n
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+/**
+* poFormat reads GNU gettext catalogs and writes the pot templates,
+* the msgctxt is a key prefix joined with the splitter, msgstr[n] are mapped to the CLDR categories,
+* the language is read from the Language header or from the file path
+**/
+type poFormat struct{}
+
+// poEntry is a message of a gettext catalog
+type poEntry struct {
+	comments   []string
+	references []string
+	flags      []string
+	context    string
+	id         string
+	idPlural   string
+	str        string
+	strs       []string
+}
+
+func (poFormat) Extensions() []string {
+	return []string{"po"}
+}
+
+func (poFormat) Decode(data []byte, file FormatFile) (*I18nDict, error) {
+	entries, err := decodePO(data)
+	if err != nil {
+		return nil, err
+	}
+	return entriesDict(entries, file)
+}
+
+func (poFormat) Encode(dict *I18nDict) ([]byte, error) {
+	return encodePO(dict, false), nil
+}
+
+func (poFormat) TemplateExtension() string {
+	return "pot"
+}
+
+func (poFormat) EncodeTemplate(dict *I18nDict) ([]byte, error) {
+	return encodePO(dict, true), nil
+}
+
+func decodePO(data []byte) ([]*poEntry, error) {
+	var (
+		entries []*poEntry
+		entry   = &poEntry{}
+		// the string continued by the following quoted lines
+		last  *string
+		hasID bool
+	)
+	finish := func() {
+		if hasID {
+			entries = append(entries, entry)
+		}
+		entry, last, hasID = &poEntry{}, nil, false
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			finish()
+		case strings.HasPrefix(line, "#~"):
+			// obsolete messages are dropped
+			last = nil
+		case strings.HasPrefix(line, "#"):
+			if hasID {
+				finish()
+			}
+			switch {
+			case strings.HasPrefix(line, "#:"):
+				entry.references = append(entry.references, strings.Fields(line[2:])...)
+			case strings.HasPrefix(line, "#,"):
+				for _, flag := range strings.Split(line[2:], ",") {
+					if flag = strings.TrimSpace(flag); flag != "" {
+						entry.flags = append(entry.flags, flag)
+					}
+				}
+			case strings.HasPrefix(line, "#."), strings.HasPrefix(line, "#|"):
+				// extracted comments and previous messages are regenerated by the tools
+			default:
+				entry.comments = append(entry.comments, strings.TrimPrefix(line[1:], " "))
+			}
+		case strings.HasPrefix(line, `"`):
+			if last == nil {
+				return nil, fmt.Errorf("Invalid po file, line %v: unexpected string", i+1)
+			}
+			val, err := poUnquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid po file, line %v: %v", i+1, err)
+			}
+			*last += val
+		default:
+			keyword, rest := line, ""
+			if idx := strings.IndexAny(line, " \t"); idx >= 0 {
+				keyword, rest = line[:idx], strings.TrimSpace(line[idx:])
+			}
+			val, err := poUnquote(rest)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid po file, line %v: %v", i+1, err)
+			}
+			switch {
+			case keyword == "msgctxt":
+				if hasID {
+					finish()
+				}
+				entry.context = val
+				last = &entry.context
+			case keyword == "msgid":
+				if hasID {
+					finish()
+				}
+				entry.id, hasID = val, true
+				last = &entry.id
+			case keyword == "msgid_plural":
+				entry.idPlural = val
+				last = &entry.idPlural
+			case keyword == "msgstr":
+				entry.str = val
+				last = &entry.str
+			case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+				n, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+				if err != nil || n < 0 || n > len(entry.strs) {
+					return nil, fmt.Errorf("Invalid po file, line %v: unexpected %v", i+1, keyword)
+				}
+				if n == len(entry.strs) {
+					entry.strs = append(entry.strs, val)
+				} else {
+					entry.strs[n] = val
+				}
+				last = &entry.strs[n]
+			default:
+				return nil, fmt.Errorf("Invalid po file, line %v: unknown keyword %v", i+1, keyword)
+			}
+		}
+	}
+	finish()
+	return entries, nil
+}
+
+/**
+* entriesDict converts the gettext messages of a file to a dict,
+* the language missing from the headers is taken from the file path,
+* the Plural-Forms header maps msgstr[n] to the categories, the table of the language is used without it
+**/
+func entriesDict(entries []*poEntry, file FormatFile) (*I18nDict, error) {
+	d := &I18nDict{
+		Dict:       make(dict),
+		Plural:     make(pluralDict),
+		Comments:   make(map[string]string),
+		References: make(map[string][]string),
+		Flags:      make(map[string][]string),
+	}
+	for _, entry := range entries {
+		if entry.id != "" || entry.context != "" {
+			continue
+		}
+		headers := poHeaders(entry.str)
+		d.Lang = headers["Language"]
+		d.PluralForms = headers["Plural-Forms"]
+		if comment := strings.Join(entry.comments, "\n"); comment != "" {
+			d.Comments[""] = comment
+		}
+	}
+	if d.Lang == "" {
+		d.Lang = file.Lang
+	}
+	if language.IsSupported(d.Lang) {
+		d.Lang = language.GetLang(d.Lang).Shortcut()
+	}
+	d.Namespace = file.Namespace
+	forms := gettextPluralOf(language.GetLang(d.Lang))
+	if d.PluralForms != "" {
+		p, err := parsePluralForms(d.PluralForms, language.GetLang(d.Lang))
+		if err != nil {
+			return nil, err
+		}
+		forms = p
+	}
+	for _, entry := range entries {
+		if entry.id == "" && entry.context == "" {
+			continue
+		}
+		key := entry.id
+		if entry.context != "" {
+			key = entry.context + file.Splitter + entry.id
+		}
+		if entry.context != "" || entry.idPlural != "" {
+			if d.Gettext == nil {
+				d.Gettext = make(map[string]GettextMessage)
+			}
+			d.Gettext[key] = GettextMessage{Context: entry.context, ID: entry.id, IDPlural: entry.idPlural}
+		}
+		if entry.idPlural != "" {
+			p, err := forms.toPlural(entry.strs)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid plural translation of key: %v, error: %v", key, err)
+			}
+			d.Plural[key] = p
+		} else {
+			d.Dict[key] = entry.str
+		}
+		if len(entry.comments) > 0 {
+			d.Comments[key] = strings.Join(entry.comments, "\n")
+		}
+		if len(entry.references) > 0 {
+			d.References[key] = entry.references
+		}
+		if len(entry.flags) > 0 {
+			d.Flags[key] = entry.flags
+		}
+	}
+	return d, nil
+}
+
+// poHeaders parses the "Name: value" lines of the header message
+func poHeaders(header string) map[string]string {
+	res := make(map[string]string)
+	for _, line := range strings.Split(header, "\n") {
+		if idx := strings.Index(line, ":"); idx > 0 {
+			res[strings.TrimSpace(line[:idx])] = strings.TrimSpace(line[idx+1:])
+		}
+	}
+	return res
+}
+
+func poUnquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("expect a quoted string: %v", s)
+	}
+	s = s[1 : len(s)-1]
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i++; i == len(s) {
+			return "", fmt.Errorf("unterminated escape sequence")
+		}
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '\\', '"', '\'', '?':
+			b.WriteByte(s[i])
+		default:
+			return "", fmt.Errorf("unknown escape sequence: \\%c", s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+func poQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// writePOString writes multi-line strings with one line per quoted string
+func writePOString(buf *bytes.Buffer, keyword string, val string) {
+	lines := strings.SplitAfter(val, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= 1 {
+		fmt.Fprintf(buf, "%v %v\n", keyword, poQuote(val))
+		return
+	}
+	fmt.Fprintf(buf, "%v \"\"\n", keyword)
+	for _, line := range lines {
+		buf.WriteString(poQuote(line) + "\n")
+	}
+}
+
+func writePOComment(buf *bytes.Buffer, prefix string, comment string) {
+	for _, line := range strings.Split(comment, "\n") {
+		buf.WriteString(strings.TrimRight(prefix+" "+line, " ") + "\n")
+	}
+}
+
+/**
+* encodePO writes the dict as a po file, or as a pot template without translations,
+* the keys are written as msgid, plural keys are also their own msgid_plural,
+* unless they were read from a gettext catalog
+**/
+func encodePO(d *I18nDict, template bool) []byte {
+	var buf bytes.Buffer
+	if comment := d.Comments[""]; comment != "" {
+		writePOComment(&buf, "#", comment)
+	}
+	forms := gettextPluralOfHeader(d.PluralForms, language.GetLang(d.Lang))
+	headers := []string{
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: 8bit",
+	}
+	if template {
+		headers = append(headers, "Plural-Forms: nplurals=INTEGER; plural=EXPRESSION;")
+	} else {
+		headers = append([]string{"Language: " + d.Lang}, headers...)
+		headers = append(headers, "Plural-Forms: "+forms.forms)
+	}
+	buf.WriteString("msgid \"\"\nmsgstr \"\"\n")
+	for _, header := range headers {
+		buf.WriteString(poQuote(header+"\n") + "\n")
+	}
+
+	keys := make([]string, 0, len(d.Dict)+len(d.Plural))
+	for key := range d.Dict {
+		if _, ok := d.Plural[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	for key := range d.Plural {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		buf.WriteString("\n")
+		if comment := d.Comments[key]; comment != "" {
+			writePOComment(&buf, "#", comment)
+		}
+		if refs := d.References[key]; len(refs) > 0 {
+			buf.WriteString("#: " + strings.Join(refs, " ") + "\n")
+		}
+		if flags := d.Flags[key]; len(flags) > 0 {
+			buf.WriteString("#, " + strings.Join(flags, ", ") + "\n")
+		}
+		// the keys read from gettext catalogs keep their msgctxt and msgid_plural
+		msg, ok := d.Gettext[key]
+		if !ok {
+			msg = GettextMessage{ID: key, IDPlural: key}
+		}
+		if msg.Context != "" {
+			writePOString(&buf, "msgctxt", msg.Context)
+		}
+		writePOString(&buf, "msgid", msg.ID)
+		if p, ok := d.Plural[key]; ok {
+			if msg.IDPlural == "" {
+				msg.IDPlural = msg.ID
+			}
+			writePOString(&buf, "msgid_plural", msg.IDPlural)
+			strs := make([]string, 2)
+			if !template {
+				strs = forms.fromPlural(p)
+			}
+			for i, str := range strs {
+				writePOString(&buf, fmt.Sprintf("msgstr[%d]", i), str)
+			}
+		} else if template {
+			writePOString(&buf, "msgstr", "")
+		} else {
+			writePOString(&buf, "msgstr", d.Dict[key])
+		}
+	}
+	return buf.Bytes()
+}
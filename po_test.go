@@ -0,0 +1,128 @@
+package i18n
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+func TestPORoundTrip(t *testing.T) {
+	d := &I18nDict{
+		Lang:      "ru",
+		Namespace: "ru.app",
+		Dict: dict{
+			"hello":        "Привет",
+			"menu|open":    "Открыть",
+			"quoted":       "он сказал \"привет\"\tи ушёл\\",
+			"multiline":    "первая\nвторая",
+			"untranslated": "",
+		},
+		Plural: pluralDict{
+			"%d file": {language.PluralOne: "%d файл", language.PluralFew: "%d файла", language.PluralMany: "%d файлов"},
+		},
+		Comments: map[string]string{
+			"":      "app strings",
+			"hello": "greeting\nshown on start",
+		},
+		References: map[string][]string{"hello": {"main.go:12", "app/app.go:3"}},
+		Flags:      map[string][]string{"quoted": {"fuzzy", "c-format"}},
+		Gettext: map[string]GettextMessage{
+			"menu|open": {Context: "menu", ID: "open"},
+			"%d file":   {ID: "%d file", IDPlural: "%d files"},
+		},
+	}
+	got := roundTrip(t, poFormat{}, d, FormatFile{Namespace: "ru.app", Splitter: "|"})
+	// the last form also serves other
+	want := d.Clone()
+	want.Plural["%d file"][language.PluralOther] = "%d файлов"
+	assertDict(t, got, want)
+	if !reflect.DeepEqual(got.References, d.References) {
+		t.Errorf("References = %v, want %v", got.References, d.References)
+	}
+	if !reflect.DeepEqual(got.Flags, d.Flags) {
+		t.Errorf("Flags = %v, want %v", got.Flags, d.Flags)
+	}
+	if !reflect.DeepEqual(got.Gettext, d.Gettext) {
+		t.Errorf("Gettext = %v, want %v", got.Gettext, d.Gettext)
+	}
+	if !got.IsFuzzy("quoted") || got.IsFuzzy("hello") {
+		t.Errorf("IsFuzzy(quoted), IsFuzzy(hello) = %v, %v, want true, false", got.IsFuzzy("quoted"), got.IsFuzzy("hello"))
+	}
+}
+
+func TestParsePluralForms(t *testing.T) {
+	tests := []struct {
+		lang      language.I18nLang
+		header    string
+		want      []language.PluralCategory
+		unclaimed map[int]bool
+	}{
+		{language.English, "nplurals=2; plural=(n != 1);", []language.PluralCategory{language.PluralOne, language.PluralOther}, nil},
+		{language.French, "nplurals=2; plural=(n > 1);", []language.PluralCategory{language.PluralOne, language.PluralOther}, nil},
+		{
+			language.Russian,
+			"nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+			[]language.PluralCategory{language.PluralOne, language.PluralFew, language.PluralMany},
+			nil,
+		},
+		{
+			language.Latvian,
+			"nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n != 0 ? 1 : 2);",
+			[]language.PluralCategory{language.PluralOne, language.PluralOther, language.PluralZero},
+			nil,
+		},
+		// the forms without a CLDR category of their own serve other
+		{language.Japanese, "nplurals=2; plural=n != 1;", []language.PluralCategory{language.PluralOther, language.PluralOther}, map[int]bool{0: true}},
+		{language.Indonesian, "nplurals=2; plural=(n > 1);", []language.PluralCategory{language.PluralOther, language.PluralOther}, map[int]bool{0: true}},
+		{language.English, "nplurals=3; plural=(n == 0 ? 2 : n == 1 ? 0 : 1);", []language.PluralCategory{language.PluralOne, language.PluralOther, language.PluralOther}, map[int]bool{2: true}},
+	}
+	for _, tt := range tests {
+		p, err := parsePluralForms(tt.header, tt.lang)
+		if err != nil {
+			t.Errorf("parsePluralForms(%v, %v) error: %v", tt.header, tt.lang.Shortcut(), err)
+			continue
+		}
+		if !reflect.DeepEqual(p.categories, tt.want) || !reflect.DeepEqual(p.unclaimed, tt.unclaimed) {
+			t.Errorf("parsePluralForms(%v, %v) = %v, %v, want %v, %v", tt.header, tt.lang.Shortcut(), p.categories, p.unclaimed, tt.want, tt.unclaimed)
+		}
+	}
+	for _, header := range []string{"nplurals=x; plural=0;", "nplurals=2;", "nplurals=2; plural=n+5;", "nplurals=2; plural=(n >;"} {
+		if _, err := parsePluralForms(header, language.English); err == nil {
+			t.Errorf("parsePluralForms(%v) succeeded", header)
+		}
+	}
+}
+
+func TestPODecodeUnclaimedForms(t *testing.T) {
+	data := []byte(`msgid ""
+msgstr ""
+"Language: ja\n"
+"Plural-Forms: nplurals=2; plural=n != 1;\n"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "1 ファイル"
+msgstr[1] "%d ファイル"
+`)
+	got, err := poFormat{}.Decode(data, FormatFile{Splitter: "."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := plural{language.PluralOther: "%d ファイル"}
+	if !reflect.DeepEqual(got.Plural["%d file"], want) {
+		t.Errorf("Plural = %v, want %v", got.Plural["%d file"], want)
+	}
+	// the header is kept, both forms are written back
+	data, err = poFormat{}.Encode(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := poFormat{}.Decode(data, FormatFile{Splitter: "."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again.Plural, got.Plural) {
+		t.Errorf("Plural after round trip = %v, want %v", again.Plural, got.Plural)
+	}
+}
@@ -12,31 +12,35 @@ type I18nReader interface {
 }
 
 type reader struct {
-	opts   *I18nOpts
-	dicts  map[string]*I18nDict
-	parser I18nParser
+	opts  *I18nOpts
+	dicts map[string]*I18nDict
 }
 
 func NewReader(opts *I18nOpts, dicts map[string]*I18nDict) I18nReader {
 	return &reader{
-		opts:   opts,
-		dicts:  dicts,
-		parser: ParserFactory(opts),
+		opts:  opts,
+		dicts: dicts,
 	}
 }
 
+// ReadAllFile reads all files of the file type, it fails if the file type is not registered
 func (r *reader) ReadAllFile() error {
 	var s []string
-	files, err := ReadAllPath(r.opts.dir, s, r.opts.fileType)
+	parser, err := ParserFactory(r.opts)
+	if err != nil {
+		return err
+	}
+	fsys, root := r.opts.fileSystem()
+	files, err := ReadAllFSPath(fsys, root, s, strings.ToLower(r.opts.fileType))
 	if err != nil {
 		return err
 	}
 	for _, fpath := range files {
-		key := GetNamespace(strings.TrimRight(fpath, "."+strings.ToLower(r.opts.fileType)), r.opts.dir, r.opts.splitter)
+		key := fileNamespace(fpath, root, strings.ToLower(r.opts.fileType), r.opts.splitter)
 		if _, ok := r.dicts[key]; !ok {
 			r.dicts[key] = &I18nDict{}
 		}
-		r.dicts[key], err = r.parser.parse(fpath)
+		r.dicts[key], err = parser.parse(fsys, root, fpath)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,198 @@
+package i18n
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+/**
+* Source provides the dicts of a layer from another backend than the translation files, like a database,
+* the namespace of a dict includes the language prefix like the files, e.g. en.billing,
+* it is empty for the keys of the general dict
+**/
+type Source interface {
+	// Languages lists the shortcuts of the languages of the source
+	Languages() ([]string, error)
+	// Fetch returns the dicts of the language, one per namespace
+	Fetch(lang string) ([]*I18nDict, error)
+}
+
+/**
+* WatchableSource notifies its changes, Bundle.Watch fetches the source again on the next tick after a notification,
+* the sources without notifications are fetched again by Load and Reload only
+**/
+type WatchableSource interface {
+	Source
+	// Changes returns a channel receiving a value after each change, it is closed once ctx is done
+	Changes(ctx context.Context) <-chan struct{}
+}
+
+/**
+* FileSource reads the translation files of a language directory,
+* a Layer without source reads the same files but only parses the modified ones on refresh
+**/
+type FileSource struct {
+	opts *I18nOpts
+	fsys fs.FS
+	root string
+}
+
+// NewFileSource returns a source reading the language directory dir inside fsys, the os file system if fsys is nil
+func NewFileSource(opts *I18nOpts, fsys fs.FS, dir string) *FileSource {
+	fsys, root := Layer{FS: fsys, Dir: dir}.fileSystem()
+	return &FileSource{opts: opts, fsys: fsys, root: root}
+}
+
+// Languages lists the directories named after a supported language
+func (src *FileSource) Languages() ([]string, error) {
+	entries, err := fs.ReadDir(src.fsys, src.root)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, entry := range entries {
+		if entry.IsDir() && language.IsSupported(entry.Name()) {
+			res = append(res, entry.Name())
+		}
+	}
+	return res, nil
+}
+
+func (src *FileSource) Fetch(lang string) ([]*I18nDict, error) {
+	parser, err := ParserFactory(src.opts)
+	if err != nil {
+		return nil, err
+	}
+	var s []string
+	paths, err := ReadAllFSPath(src.fsys, path.Join(src.root, lang), s, src.opts.fileType)
+	if err != nil {
+		return nil, err
+	}
+	dicts := make([]*I18nDict, 0, len(paths))
+	for _, fpath := range paths {
+		d, err := parser.parse(src.fsys, src.root, fpath)
+		if err != nil {
+			return nil, err
+		}
+		dicts = append(dicts, d)
+	}
+	return dicts, nil
+}
+
+/**
+* MemorySource holds the dicts in memory, they are set and removed at runtime,
+* it notifies the changes to Bundle.Watch
+**/
+type MemorySource struct {
+	sync.RWMutex
+	// dicts by language and namespace
+	dicts map[string]map[string]*I18nDict
+	subs  map[chan struct{}]bool
+}
+
+func NewMemorySource(dicts ...*I18nDict) *MemorySource {
+	ms := &MemorySource{
+		dicts: make(map[string]map[string]*I18nDict),
+		subs:  make(map[chan struct{}]bool),
+	}
+	ms.Set(dicts...)
+	return ms
+}
+
+// sourceLang canonicalizes the language of a source, e.g. zh_TW is zh-Hant, unsupported languages are kept as is
+func sourceLang(shortcut string) string {
+	if language.IsSupported(shortcut) {
+		return language.GetLang(shortcut).Shortcut()
+	}
+	return shortcut
+}
+
+// Set replaces the dicts of the same language and namespace, the dicts are copied
+func (ms *MemorySource) Set(dicts ...*I18nDict) {
+	if len(dicts) == 0 {
+		return
+	}
+	ms.Lock()
+	for _, d := range dicts {
+		lang := sourceLang(d.Lang)
+		if _, ok := ms.dicts[lang]; !ok {
+			ms.dicts[lang] = make(map[string]*I18nDict)
+		}
+		nd := d.Clone()
+		nd.Lang = lang
+		ms.dicts[lang][d.Namespace] = nd
+	}
+	ms.Unlock()
+	ms.notify()
+}
+
+// Remove drops the dict of the language and namespace
+func (ms *MemorySource) Remove(lang string, namespace string) {
+	ms.Lock()
+	lang = sourceLang(lang)
+	delete(ms.dicts[lang], namespace)
+	if len(ms.dicts[lang]) == 0 {
+		delete(ms.dicts, lang)
+	}
+	ms.Unlock()
+	ms.notify()
+}
+
+func (ms *MemorySource) Languages() ([]string, error) {
+	ms.RLock()
+	defer ms.RUnlock()
+	res := make([]string, 0, len(ms.dicts))
+	for lang := range ms.dicts {
+		res = append(res, lang)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// Fetch returns copies of the dicts of the language, in namespace order
+func (ms *MemorySource) Fetch(lang string) ([]*I18nDict, error) {
+	ms.RLock()
+	defer ms.RUnlock()
+	namespaces := make([]string, 0, len(ms.dicts[lang]))
+	for namespace := range ms.dicts[lang] {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	res := make([]*I18nDict, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		res = append(res, ms.dicts[lang][namespace].Clone())
+	}
+	return res, nil
+}
+
+// Changes coalesces the changes not received yet into a single value
+func (ms *MemorySource) Changes(ctx context.Context) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	ms.Lock()
+	ms.subs[ch] = true
+	ms.Unlock()
+	go func() {
+		<-ctx.Done()
+		ms.Lock()
+		delete(ms.subs, ch)
+		ms.Unlock()
+		close(ch)
+	}()
+	return ch
+}
+
+func (ms *MemorySource) notify() {
+	ms.RLock()
+	defer ms.RUnlock()
+	for ch := range ms.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package i18n
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+/**
+* SQLSource reads the dicts from a database/sql table of this schema:
+*
+* CREATE TABLE i18n_translations (
+*     lang      VARCHAR(35)  NOT NULL,
+*     namespace VARCHAR(255) NOT NULL DEFAULT '',
+*     msg_key   VARCHAR(255) NOT NULL,
+*     category  VARCHAR(5)   NOT NULL DEFAULT '',
+*     value     TEXT         NOT NULL,
+*     PRIMARY KEY (lang, namespace, msg_key, category)
+* );
+*
+* lang is the language shortcut, e.g. en or zh-Hant, the other spellings like zh_TW are read as the canonical shortcut,
+* namespace is the namespace without the language prefix, e.g. billing.invoice, empty for the general dict,
+* category is empty for a plain string, a CLDR plural category for a plural form, the plural forms replace the plain string of the key,
+* NULL is read as empty in namespace and category,
+* it does not notify its changes, call Bundle.Reload after editing the table
+**/
+type SQLSource struct {
+	opts  *I18nOpts
+	db    *sql.DB
+	table string
+	// Placeholder is the bind parameter of the driver, "?" by default, "$1" for postgres
+	Placeholder string
+}
+
+// NewSQLSource returns a source reading the table, the table name is written as is in the queries
+func NewSQLSource(opts *I18nOpts, db *sql.DB, table string) *SQLSource {
+	return &SQLSource{
+		opts:        opts,
+		db:          db,
+		table:       table,
+		Placeholder: "?",
+	}
+}
+
+// Languages returns the canonical shortcuts of the stored languages, zh_TW and zh-Hant are both listed as zh-Hant
+func (src *SQLSource) Languages() ([]string, error) {
+	stored, err := src.storedLangs()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(stored))
+	res := make([]string, 0, len(stored))
+	for _, lang := range stored {
+		if shortcut := sourceLang(lang); !seen[shortcut] {
+			seen[shortcut] = true
+			res = append(res, shortcut)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// storedLangs returns the languages as spelled in the table
+func (src *SQLSource) storedLangs() ([]string, error) {
+	rows, err := src.db.Query(fmt.Sprintf("SELECT DISTINCT lang FROM %v", src.table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []string
+	for rows.Next() {
+		var lang string
+		if err := rows.Scan(&lang); err != nil {
+			return nil, err
+		}
+		res = append(res, lang)
+	}
+	return res, rows.Err()
+}
+
+/**
+* Fetch returns the dicts of the language in namespace order,
+* the rows of every spelling of the language are merged, those spelled like the canonical shortcut win
+**/
+func (src *SQLSource) Fetch(lang string) ([]*I18nDict, error) {
+	// the dicts use the canonical shortcut whatever the spelling stored in the table, e.g. zh_TW
+	shortcut := sourceLang(lang)
+	stored, err := src.storedLangs()
+	if err != nil {
+		return nil, err
+	}
+	var spellings []string
+	for _, s := range stored {
+		if sourceLang(s) == shortcut && s != shortcut {
+			spellings = append(spellings, s)
+		}
+	}
+	sort.Strings(spellings)
+	spellings = append(spellings, shortcut)
+	dicts := make(map[string]*I18nDict)
+	for _, s := range spellings {
+		if err := src.fetch(s, shortcut, dicts); err != nil {
+			return nil, err
+		}
+	}
+	namespaces := make([]string, 0, len(dicts))
+	for namespace := range dicts {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	res := make([]*I18nDict, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		res = append(res, dicts[namespace])
+	}
+	return res, nil
+}
+
+// fetch merges the rows stored with the spelling into the dicts of shortcut, keyed by namespace
+func (src *SQLSource) fetch(spelling string, shortcut string, dicts map[string]*I18nDict) error {
+	query := fmt.Sprintf("SELECT namespace, msg_key, category, value FROM %v WHERE lang = %v ORDER BY namespace, msg_key, category", src.table, src.Placeholder)
+	rows, err := src.db.Query(query, spelling)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			namespace, category sql.NullString
+			key, value          string
+		)
+		if err := rows.Scan(&namespace, &key, &category, &value); err != nil {
+			return err
+		}
+		fullNamespace := ""
+		if namespace.String != "" {
+			fullNamespace = shortcut + src.opts.splitter + namespace.String
+		}
+		d, ok := dicts[fullNamespace]
+		if !ok {
+			d = &I18nDict{
+				Lang:      shortcut,
+				Namespace: fullNamespace,
+				Dict:      make(dict),
+				Plural:    make(pluralDict),
+			}
+			dicts[fullNamespace] = d
+		}
+		if category.String == "" {
+			if _, ok := d.Plural[key]; !ok {
+				d.Dict[key] = value
+			}
+			continue
+		}
+		if !language.IsPluralCategory(category.String) {
+			return fmt.Errorf("Invalid plural category: %v of key: %v", category.String, key)
+		}
+		if _, ok := d.Plural[key]; !ok {
+			d.Plural[key] = make(plural)
+			// a plural key replaces the plain string
+			delete(d.Dict, key)
+		}
+		d.Plural[key][language.PluralCategory(category.String)] = value
+	}
+	return rows.Err()
+}
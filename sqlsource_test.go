@@ -0,0 +1,222 @@
+package i18n
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// testRow is a row of the translations table served by testDriver
+type testRow struct {
+	lang, namespace, key, category, value string
+}
+
+// testTable holds the rows of the in-process driver, keyed by the data source name
+var testTable = map[string][]testRow{}
+
+func init() {
+	sql.Register("i18ntest", testDriver{})
+}
+
+/**
+* testDriver answers the two queries of SQLSource from testTable,
+* the rows are expected in the ORDER BY of Fetch
+**/
+type testDriver struct{}
+
+func (testDriver) Open(name string) (driver.Conn, error) {
+	return testConn{testTable[name]}, nil
+}
+
+type testConn struct {
+	rows []testRow
+}
+
+func (c testConn) Prepare(query string) (driver.Stmt, error) {
+	return testStmt{c.rows, query}, nil
+}
+
+func (testConn) Close() error {
+	return nil
+}
+
+func (testConn) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
+
+type testStmt struct {
+	rows  []testRow
+	query string
+}
+
+func (testStmt) Close() error {
+	return nil
+}
+
+func (s testStmt) NumInput() int {
+	return strings.Count(s.query, "?")
+}
+
+func (testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+
+func (s testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	res := &testRows{}
+	seen := make(map[string]bool)
+	for _, row := range s.rows {
+		if strings.Contains(s.query, "DISTINCT lang") {
+			if !seen[row.lang] {
+				seen[row.lang] = true
+				res.values = append(res.values, []driver.Value{row.lang})
+			}
+			continue
+		}
+		if row.lang == args[0] {
+			res.values = append(res.values, []driver.Value{row.namespace, row.key, row.category, row.value})
+		}
+	}
+	if strings.Contains(s.query, "DISTINCT lang") {
+		res.columns = []string{"lang"}
+	} else {
+		res.columns = []string{"namespace", "msg_key", "category", "value"}
+	}
+	return res, nil
+}
+
+type testRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *testRows) Columns() []string {
+	return r.columns
+}
+
+func (r *testRows) Close() error {
+	return nil
+}
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestSQLSourceFetch(t *testing.T) {
+	testTable[t.Name()] = []testRow{
+		{"en", "", "files", "one", "%d file"},
+		{"en", "", "files", "other", "%d files"},
+		{"en", "", "hello", "", "Hello"},
+		{"en", "billing", "total", "", "Total"},
+		{"zh_TW", "", "hello", "", "你好"},
+		{"zh_TW", "billing", "total", "", "總計"},
+		{"zh-Hant", "", "bye", "", "再見"},
+		{"zh-Hant", "", "hello", "", "您好"},
+		{"zh-TW", "billing", "due", "", "到期"},
+	}
+	db, err := sql.Open("i18ntest", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	opts := NewI18nOpts()
+	opts.SetEnableLangs("en,zh-Hant")
+	src := NewSQLSource(opts, db, "i18n_translations")
+
+	langs, err := src.Languages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(langs, []string{"en", "zh-Hant"}) {
+		t.Fatalf("Languages() = %v, want [en zh-Hant]", langs)
+	}
+
+	dicts, err := src.Fetch("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dicts) != 2 || dicts[0].Namespace != "" || dicts[1].Namespace != "en.billing" {
+		t.Fatalf("Fetch(en) = %+v, want the general dict and en.billing", dicts)
+	}
+	if val := dicts[0].Dict["hello"]; val != "Hello" {
+		t.Errorf("hello = %v, want Hello", val)
+	}
+	if forms := dicts[0].Plural["files"]; forms["one"] != "%d file" || forms["other"] != "%d files" {
+		t.Errorf("files = %v, want the one and other forms", forms)
+	}
+	if val := dicts[1].Dict["total"]; val != "Total" {
+		t.Errorf("billing total = %v, want Total", val)
+	}
+
+	dicts, err = src.Fetch("zh_TW")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dicts) != 2 || dicts[0].Lang != "zh-Hant" || dicts[1].Namespace != "zh-Hant.billing" {
+		t.Fatalf("Fetch(zh_TW) = %+v, want the dicts of zh-Hant", dicts)
+	}
+	// every spelling is read, the canonical one wins
+	tests := []struct {
+		dict int
+		key  string
+		want string
+	}{
+		{0, "hello", "您好"},
+		{0, "bye", "再見"},
+		{1, "total", "總計"},
+		{1, "due", "到期"},
+	}
+	for _, tt := range tests {
+		if val := dicts[tt.dict].Dict[tt.key]; val != tt.want {
+			t.Errorf("Fetch(zh_TW) %v = %v, want %v", tt.key, val, tt.want)
+		}
+	}
+	canonical, err := src.Fetch("zh-Hant")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(canonical, dicts) {
+		t.Errorf("Fetch(zh-Hant) = %+v, want %+v", canonical, dicts)
+	}
+}
+
+func TestSQLSourceLayer(t *testing.T) {
+	testTable[t.Name()] = []testRow{
+		{"en", "", "files", "one", "%d file"},
+		{"en", "", "files", "other", "%d files"},
+		{"en", "", "hello", "", "Hello"},
+		{"zh_TW", "", "hello", "", "你好"},
+	}
+	db, err := sql.Open("i18ntest", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	opts := NewI18nOpts()
+	opts.SetEnableLangs("en,zh-Hant")
+	opts.SetTargetLang("en")
+	opts.SetLanguageDir(t.TempDir())
+	opts.AddLayer(SourceLayer("db", NewSQLSource(opts, db, "i18n_translations")))
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	b := NewBundle(opts, log)
+	if err := b.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if val := b.TransPlural("files", 2, 2); val != "2 files" {
+		t.Errorf("TransPlural(files) = %v, want 2 files", val)
+	}
+	if val := b.NewLocalizer("zh-Hant").Trans("hello"); val != "你好" {
+		t.Errorf("Trans(hello) of zh-Hant = %v, want 你好", val)
+	}
+}
@@ -0,0 +1,294 @@
+package i18n
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultTenantCapacity is the number of tenants whose overrides are kept in memory by default
+	defaultTenantCapacity = 1024
+	// the retry delay of a tenant whose overrides failed to load, doubled on each failure in a row
+	minTenantRetry = time.Second
+	maxTenantRetry = 5 * time.Minute
+)
+
+/**
+* TenantLoader loads the override dicts of a tenant when they are not in memory,
+* it returns no dict if the tenant has no overrides,
+* the namespace of a dict includes the language prefix like the files, e.g. en.billing
+**/
+type TenantLoader func(tenant string) ([]*I18nDict, error)
+
+// SetTenantLoader sets the loader of the tenant overrides missing from memory
+func (opts *I18nOpts) SetTenantLoader(loader TenantLoader) {
+	opts.tenantLoader = loader
+}
+
+// SetTenantCapacity bounds the number of loaded tenants kept in memory, the least recently used tenants are dropped first
+func (opts *I18nOpts) SetTenantCapacity(capacity int) {
+	opts.tenantCapacity = capacity
+}
+
+/**
+* TenantFiles returns a loader reading the overrides of a tenant from the directory named after the tenant in the layer,
+* laid out like the language directory: <dir>/<tenant>/en/index.json
+**/
+func (opts *I18nOpts) TenantFiles(layer Layer) TenantLoader {
+	return func(tenant string) ([]*I18nDict, error) {
+		if !fs.ValidPath(tenant) || tenant == "." || tenant == ".." || strings.ContainsAny(tenant, `/\`) {
+			return nil, fmt.Errorf("Invalid tenant: %v", tenant)
+		}
+		parser, err := ParserFactory(opts)
+		if err != nil {
+			return nil, err
+		}
+		var s []string
+		fsys, root := layer.fileSystem()
+		root = path.Join(root, tenant)
+		paths, err := ReadAllFSPath(fsys, root, s, opts.fileType)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		dicts := make([]*I18nDict, 0, len(paths))
+		for _, fpath := range paths {
+			d, err := parser.parse(fsys, root, fpath)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", fpath, err)
+			}
+			dicts = append(dicts, d)
+		}
+		return dicts, nil
+	}
+}
+
+// tenantCache keeps the override catalogs of the recently used tenants and those set with SetTenant
+type tenantCache struct {
+	sync.Mutex
+	entries map[string]*list.Element
+	// the most recently used tenant is at the front
+	order *list.List
+	// the overrides set with SetTenant, they are kept out of the lru
+	pinned map[string]*catalog
+	// the loads in progress, the concurrent lookups of a tenant wait for the same load
+	loading map[string]*tenantLoad
+}
+
+type tenantEntry struct {
+	tenant  string
+	catalog *catalog
+	// set if the load failed, the tenant is not loaded again before retryAt
+	retryAt time.Time
+	backoff time.Duration
+}
+
+type tenantLoad struct {
+	done    chan struct{}
+	catalog *catalog
+}
+
+func newTenantCache() *tenantCache {
+	return &tenantCache{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		pinned:  make(map[string]*catalog),
+		loading: make(map[string]*tenantLoad),
+	}
+}
+
+// get returns the overrides of the tenant, ok is false if they must be loaded
+func (tc *tenantCache) get(tenant string) (*catalog, bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	return tc.getLocked(tenant)
+}
+
+func (tc *tenantCache) getLocked(tenant string) (*catalog, bool) {
+	if c, ok := tc.pinned[tenant]; ok {
+		return c, true
+	}
+	elem, ok := tc.entries[tenant]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*tenantEntry)
+	if !entry.retryAt.IsZero() && !time.Now().Before(entry.retryAt) {
+		return nil, false
+	}
+	tc.order.MoveToFront(elem)
+	return entry.catalog, true
+}
+
+/**
+* startLoad returns the load of the tenant, leader is true if the caller must run it and then call finishLoad,
+* the load is already done if the overrides were stored since the last get
+**/
+func (tc *tenantCache) startLoad(tenant string) (load *tenantLoad, leader bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	if load, ok := tc.loading[tenant]; ok {
+		return load, false
+	}
+	if c, ok := tc.getLocked(tenant); ok {
+		load = &tenantLoad{done: make(chan struct{}), catalog: c}
+		close(load.done)
+		return load, false
+	}
+	load = &tenantLoad{done: make(chan struct{})}
+	tc.loading[tenant] = load
+	return load, true
+}
+
+func (tc *tenantCache) finishLoad(tenant string, load *tenantLoad) {
+	tc.Lock()
+	defer tc.Unlock()
+	delete(tc.loading, tenant)
+	close(load.done)
+}
+
+// put stores the loaded catalog of the tenant and drops the least recently used tenants above capacity
+func (tc *tenantCache) put(tenant string, c *catalog, capacity int) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.putLocked(&tenantEntry{tenant: tenant, catalog: c}, capacity)
+}
+
+/**
+* fail records a failed load of the tenant, it is loaded again once the returned delay is elapsed,
+* the delay doubles on each failure in a row up to maxTenantRetry
+**/
+func (tc *tenantCache) fail(tenant string, capacity int) time.Duration {
+	tc.Lock()
+	defer tc.Unlock()
+	backoff := minTenantRetry
+	if elem, ok := tc.entries[tenant]; ok {
+		if prev := elem.Value.(*tenantEntry).backoff; prev > 0 {
+			backoff = prev * 2
+		}
+	}
+	if backoff > maxTenantRetry {
+		backoff = maxTenantRetry
+	}
+	tc.putLocked(&tenantEntry{tenant: tenant, retryAt: time.Now().Add(backoff), backoff: backoff}, capacity)
+	return backoff
+}
+
+func (tc *tenantCache) putLocked(entry *tenantEntry, capacity int) {
+	if elem, ok := tc.entries[entry.tenant]; ok {
+		elem.Value = entry
+		tc.order.MoveToFront(elem)
+	} else {
+		tc.entries[entry.tenant] = tc.order.PushFront(entry)
+	}
+	for capacity > 0 && tc.order.Len() > capacity {
+		oldest := tc.order.Back()
+		tc.order.Remove(oldest)
+		delete(tc.entries, oldest.Value.(*tenantEntry).tenant)
+	}
+}
+
+// pin stores the overrides of the tenant out of the lru, they replace the loaded ones
+func (tc *tenantCache) pin(tenant string, c *catalog) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.pinned[tenant] = c
+	if elem, ok := tc.entries[tenant]; ok {
+		tc.order.Remove(elem)
+		delete(tc.entries, tenant)
+	}
+}
+
+func (tc *tenantCache) remove(tenant string) {
+	tc.Lock()
+	defer tc.Unlock()
+	delete(tc.pinned, tenant)
+	if elem, ok := tc.entries[tenant]; ok {
+		tc.order.Remove(elem)
+		delete(tc.entries, tenant)
+	}
+}
+
+/**
+* SetTenant replaces the overrides of the tenant, they are searched before the catalog by the Ctx lookups,
+* they are kept until RemoveTenant whatever the capacity and the tenant loader is no longer called for the tenant,
+* nothing is replaced if a dict is of a language not enabled
+**/
+func (b *Bundle) SetTenant(tenant string, dicts ...*I18nDict) error {
+	c, err := b.tenantCatalog(tenant, dicts)
+	if err != nil {
+		return err
+	}
+	b.tenants.pin(tenant, c)
+	return nil
+}
+
+// RemoveTenant drops the overrides of the tenant and any failed load, they are loaded again by the tenant loader on the next lookup
+func (b *Bundle) RemoveTenant(tenant string) {
+	b.tenants.remove(tenant)
+}
+
+/**
+* tenantCatalog merges the dicts of the tenant, the namespaced dicts go to their namespace in namespace mode,
+* a dict of a language not enabled fails the whole tenant rather than serving part of its overrides
+**/
+func (b *Bundle) tenantCatalog(tenant string, dicts []*I18nDict) (*catalog, error) {
+	for _, d := range dicts {
+		if !b.opts.IsEnabled(d.Lang) {
+			return nil, fmt.Errorf("Unsupported language: %v of tenant: %v", d.Lang, tenant)
+		}
+	}
+	c := newCatalog()
+	for _, d := range dicts {
+		if b.opts.enableNamespace && d.Namespace != "" {
+			c.mergeWithNameSpace(tenant, d.Namespace, d, b.log)
+		} else {
+			c.merge(tenant, d, b.log)
+		}
+	}
+	return c, nil
+}
+
+/**
+* tenantOverrides returns the overrides of the tenant, they are loaded on first use,
+* the concurrent lookups of a tenant share one load and nil is returned without tenant or if the load fails,
+* a dict of a language not enabled fails the load as well,
+* a failed tenant is not loaded again before its retry delay
+**/
+func (b *Bundle) tenantOverrides(tenant string) *catalog {
+	if tenant == "" {
+		return nil
+	}
+	if c, ok := b.tenants.get(tenant); ok {
+		return c
+	}
+	if b.opts.tenantLoader == nil {
+		return nil
+	}
+	load, leader := b.tenants.startLoad(tenant)
+	if !leader {
+		<-load.done
+		return load.catalog
+	}
+	defer b.tenants.finishLoad(tenant, load)
+	dicts, err := b.opts.tenantLoader(tenant)
+	if err == nil {
+		load.catalog, err = b.tenantCatalog(tenant, dicts)
+	}
+	if err != nil {
+		retry := b.tenants.fail(tenant, b.opts.tenantCapacity)
+		b.log.Errorf("Failed to load overrides of tenant: %v, retry in: %v, error: %v", tenant, retry, err)
+		return nil
+	}
+	// tenants without overrides are kept as well so they are not loaded on every lookup
+	b.tenants.put(tenant, load.catalog, b.opts.tenantCapacity)
+	return load.catalog
+}
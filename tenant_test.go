@@ -0,0 +1,213 @@
+package i18n
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+func TestTenantLoadFailureIsCached(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "en.json", `{"language":"en","dict":{"hello":"Hello"}}`)
+	b := newTestBundle(t, dir)
+	var calls int32
+	release := make(chan struct{})
+	b.opts.SetTenantLoader(func(tenant string) ([]*I18nDict, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return nil, errors.New("unavailable")
+	})
+	ctx := WithTenant(context.Background(), "acme")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if val := b.TransCtx(ctx, "hello"); val != "Hello" {
+				t.Errorf("TransCtx(hello) = %v, want Hello", val)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	for i := 0; i < 8; i++ {
+		b.TransCtx(ctx, "hello")
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("tenant loader called %d times, want 1", n)
+	}
+
+	b.RemoveTenant("acme")
+	b.TransCtx(ctx, "hello")
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("tenant loader called %d times after RemoveTenant, want 2", n)
+	}
+}
+
+func TestSetTenantSurvivesEviction(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "en.json", `{"language":"en","dict":{"hello":"Hello","items":{"one":"%d item","other":"%d items"}}}`)
+	b := newTestBundle(t, dir)
+	b.opts.SetTenantCapacity(1)
+	b.opts.SetTenantLoader(func(tenant string) ([]*I18nDict, error) {
+		return nil, nil
+	})
+	if err := b.SetTenant("acme", &I18nDict{
+		Lang:   "en",
+		Dict:   dict{"hello": "Hi {name}", "total": "{n, number} total"},
+		Plural: pluralDict{"items": plural{language.PluralOne: "%d thing", language.PluralOther: "%d things"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tenant := range []string{"a", "b", "c"} {
+		b.TransCtx(WithTenant(context.Background(), tenant), "hello")
+	}
+	ctx := WithTenant(context.Background(), "acme")
+	if val := b.TransNamedCtx(ctx, "hello", map[string]interface{}{"name": "Ann"}); val != "Hi Ann" {
+		t.Errorf("TransNamedCtx(hello) = %v, want Hi Ann", val)
+	}
+	if val := b.TransPluralCtx(ctx, "items", 2, 2); val != "2 things" {
+		t.Errorf("TransPluralCtx(items) = %v, want 2 things", val)
+	}
+	if val := b.TransMsgCtx(ctx, "total", map[string]interface{}{"n": 1200}); val != "1,200 total" {
+		t.Errorf("TransMsgCtx(total) = %v, want 1,200 total", val)
+	}
+	if val := b.TransPlural("items", 2, 2); val != "2 items" {
+		t.Errorf("TransPlural(items) = %v, want 2 items", val)
+	}
+}
+
+func TestSetTenantUnsupportedLanguage(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "en.json", `{"language":"en","dict":{"hello":"Hello"}}`)
+	b := newTestBundle(t, dir)
+	if err := b.SetTenant("acme", &I18nDict{Lang: "en", Dict: dict{"hello": "Hi"}}); err != nil {
+		t.Fatal(err)
+	}
+	err := b.SetTenant("acme", &I18nDict{Lang: "en", Dict: dict{"hello": "Hey"}}, &I18nDict{Lang: "fr", Dict: dict{"hello": "Salut"}})
+	if err == nil {
+		t.Fatalf("SetTenant with a fr dict succeeded")
+	}
+	// the previous overrides are kept
+	if val := b.TransCtx(WithTenant(context.Background(), "acme"), "hello"); val != "Hi" {
+		t.Errorf("TransCtx(hello) = %v, want Hi", val)
+	}
+}
+
+func TestTenantLoaderUnsupportedLanguage(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "en.json", `{"language":"en","dict":{"hello":"Hello"}}`)
+	b := newTestBundle(t, dir)
+	var calls int32
+	b.opts.SetTenantLoader(func(tenant string) ([]*I18nDict, error) {
+		atomic.AddInt32(&calls, 1)
+		return []*I18nDict{
+			{Lang: "en", Dict: dict{"hello": "Hi"}},
+			{Lang: "fr", Dict: dict{"hello": "Salut"}},
+		}, nil
+	})
+	ctx := WithTenant(context.Background(), "acme")
+	for i := 0; i < 3; i++ {
+		if val := b.TransCtx(ctx, "hello"); val != "Hello" {
+			t.Errorf("TransCtx(hello) = %v, want Hello", val)
+		}
+	}
+	// the failure is cached like a loader error
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("tenant loader called %d times, want 1", n)
+	}
+}
+
+func TestTenantCacheEviction(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		// tenants put, or looked up if prefixed with "get "
+		ops     []string
+		evicted []string
+		kept    []string
+	}{
+		{"unbounded", 0, []string{"a", "b", "c"}, nil, []string{"a", "b", "c"}},
+		{"oldest first", 2, []string{"a", "b", "c"}, []string{"a"}, []string{"b", "c"}},
+		{"lookup refreshes", 2, []string{"a", "b", "get a", "c"}, []string{"b"}, []string{"a", "c"}},
+		{"put again refreshes", 2, []string{"a", "b", "a", "c"}, []string{"b"}, []string{"a", "c"}},
+		{"single", 1, []string{"a", "b", "c"}, []string{"a", "b"}, []string{"c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := newTenantCache()
+			for _, op := range tt.ops {
+				if tenant := strings.TrimPrefix(op, "get "); tenant != op {
+					tc.get(tenant)
+				} else {
+					tc.put(tenant, newCatalog(), tt.capacity)
+				}
+			}
+			for _, tenant := range tt.evicted {
+				if _, ok := tc.get(tenant); ok {
+					t.Errorf("tenant %v is kept, want evicted", tenant)
+				}
+			}
+			for _, tenant := range tt.kept {
+				if _, ok := tc.get(tenant); !ok {
+					t.Errorf("tenant %v is evicted, want kept", tenant)
+				}
+			}
+			if tc.order.Len() != len(tc.entries) {
+				t.Errorf("lru holds %d tenants, entries %d", tc.order.Len(), len(tc.entries))
+			}
+		})
+	}
+}
+
+func TestTenantCacheBackoff(t *testing.T) {
+	tc := newTenantCache()
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+	for _, w := range want {
+		if backoff := tc.fail("acme", 0); backoff != w {
+			t.Errorf("fail = %v, want %v", backoff, w)
+		}
+	}
+	// a failed tenant is served without overrides until its retry delay
+	if c, ok := tc.get("acme"); !ok || c != nil {
+		t.Errorf("get = %v, %v, want nil, true", c, ok)
+	}
+	tc.entries["acme"].Value.(*tenantEntry).retryAt = time.Now().Add(-time.Millisecond)
+	if _, ok := tc.get("acme"); ok {
+		t.Errorf("get after the retry delay is found, want a new load")
+	}
+	for i := 0; i < 20; i++ {
+		tc.fail("acme", 0)
+	}
+	if backoff := tc.fail("acme", 0); backoff != maxTenantRetry {
+		t.Errorf("fail = %v, want %v", backoff, maxTenantRetry)
+	}
+	// a successful load resets the backoff
+	tc.put("acme", newCatalog(), 0)
+	if backoff := tc.fail("acme", 0); backoff != minTenantRetry {
+		t.Errorf("fail after put = %v, want %v", backoff, minTenantRetry)
+	}
+	// a failed tenant takes its place in the lru
+	tc.fail("other", 1)
+	if _, ok := tc.entries["acme"]; ok {
+		t.Errorf("tenant acme is kept, want evicted by the failed tenant")
+	}
+}
+
+func TestTenantFilesRejectsDotPaths(t *testing.T) {
+	opts := NewI18nOpts()
+	loader := opts.TenantFiles(DirLayer("tenants", t.TempDir()))
+	for _, tenant := range []string{".", "..", "a/b"} {
+		if _, err := loader(tenant); err == nil {
+			t.Errorf("TenantFiles(%q) succeeded", tenant)
+		}
+	}
+}
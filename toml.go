@@ -0,0 +1,91 @@
+package i18n
+
+import (
+	"bytes"
+
+	"github.com/BurntSushi/toml"
+)
+
+/**
+* tomlFormat reads the same layout as the json files:
+* language = "en"
+* [dict]
+* title = "Files"
+* [dict.menu]
+* open = "Open"
+* [dict.files]
+* one = "%d file"
+* other = "%d files"
+* tables are flattened with the splitter, "menu.open" above,
+* a table holding only plural categories with "other" among them is a plural entry,
+* the namespace falls back to the one derived from the path
+**/
+type tomlFormat struct{}
+
+type tomlDict struct {
+	Lang      string                 `toml:"language"`
+	Namespace string                 `toml:"namespace,omitempty"`
+	Dict      map[string]interface{} `toml:"dict"`
+}
+
+func (tomlFormat) Extensions() []string {
+	return []string{"toml"}
+}
+
+func (tomlFormat) Decode(data []byte, file FormatFile) (*I18nDict, error) {
+	d, err := decodeTOML(data, file.Splitter)
+	if err != nil {
+		return nil, err
+	}
+	if d.Namespace == "" {
+		d.Namespace = file.Namespace
+	}
+	return d, nil
+}
+
+func (tomlFormat) Encode(dict *I18nDict) ([]byte, error) {
+	return encodeTOML(dict)
+}
+
+func decodeTOML(data []byte, splitter string) (*I18nDict, error) {
+	var raw tomlDict
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return nil, err
+	}
+	d := &I18nDict{
+		Lang:      raw.Lang,
+		Namespace: raw.Namespace,
+		Dict:      make(dict),
+		Plural:    make(pluralDict),
+	}
+	if err := flattenMap(raw.Dict, "", splitter, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// encodeTOML writes the keys flat, plural entries are written as sub tables
+func encodeTOML(d *I18nDict) ([]byte, error) {
+	raw := tomlDict{
+		Lang:      d.Lang,
+		Namespace: d.Namespace,
+		Dict:      make(map[string]interface{}, len(d.Dict)+len(d.Plural)),
+	}
+	for key, val := range d.Dict {
+		raw.Dict[key] = val
+	}
+	for key, forms := range d.Plural {
+		table := make(map[string]string, len(forms))
+		for category, form := range forms {
+			table[string(category)] = form
+		}
+		raw.Dict[key] = table
+	}
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(raw); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
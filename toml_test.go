@@ -0,0 +1,56 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+func TestTOMLRoundTrip(t *testing.T) {
+	d := &I18nDict{
+		Lang:      "fr",
+		Namespace: "fr.app",
+		Dict: dict{
+			"menu.title": "Fichiers",
+			"quoted":     `il a dit "bonjour"`,
+			"multiline":  "première\nseconde",
+			"empty":      "",
+		},
+		Plural: pluralDict{
+			"files": {language.PluralOne: "%d fichier", language.PluralMany: "%d de fichiers", language.PluralOther: "%d fichiers"},
+		},
+	}
+	got := roundTrip(t, tomlFormat{}, d, FormatFile{Splitter: "."})
+	assertDict(t, got, d)
+}
+
+func TestTOMLDecode(t *testing.T) {
+	data := []byte(`language = "en"
+[dict]
+title = "Files"
+[dict.menu]
+open = "Open"
+[dict.files]
+one = "%d file"
+other = "%d files"
+[dict.size]
+one = "small"
+`)
+	got, err := tomlFormat{}.Decode(data, FormatFile{Namespace: "en.app", Splitter: "_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDict(t, got, &I18nDict{
+		Lang:      "en",
+		Namespace: "en.app",
+		Dict:      dict{"title": "Files", "menu_open": "Open", "size_one": "small"},
+		Plural: pluralDict{
+			"files": {language.PluralOne: "%d file", language.PluralOther: "%d files"},
+		},
+	})
+	for _, data := range []string{"language = ", "[dict]\nkey = 1\n", "[dict]\nkey = [\"a\"]\n"} {
+		if _, err := (tomlFormat{}).Decode([]byte(data), FormatFile{Splitter: "."}); err == nil {
+			t.Errorf("Decode(%q) succeeded", data)
+		}
+	}
+}
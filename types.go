@@ -1,6 +1,10 @@
 package i18n
 
-import "github.com/yaou-li/go-i18n/language"
+import (
+	"io/fs"
+
+	"github.com/yaou-li/go-i18n/language"
+)
 
 type I18nOptsInterface interface {
 	SetEnableLangs(shortcuts string)
@@ -10,6 +14,10 @@ type I18nOptsInterface interface {
 	SetLanguageDir(dir string)
 	SetFileType(fileType string)
 	SetEnableNamespace(enable bool)
+	SetFS(fsys fs.FS)
+	SetModulePath(modulePath string)
+	SetFallbacks(shortcut string, chain string)
+	SetFallbackHook(hook FallbackHook)
 	IsEnabled(shortcut string) bool
 }
 
@@ -19,20 +27,22 @@ type I18nInterface interface {
 	UpdateLang(shortcut string)
 	Trans(key string) string
 	Transf(key string, a ...interface{}) string
+	TransPlural(key string, count interface{}, a ...interface{}) string
+	TransMsg(key string, args map[string]interface{}) string
+	TransNamed(key string, params interface{}) string
 }
 
 type I18nLoader interface {
 	load() error
 	reload() error
-	reset()
-	get(key string) string
-	merge(data *I18nDict)
-	mergeWithNameSpace(data *I18nDict)
-	getWithNamespace(key string, namespace string) string
-	ReadAllPath(dir string, s []string) ([]string, error)
+	refresh(force bool) ([]string, error)
+	get(lang language.I18nLang, key string) string
+	getWithNamespace(lang language.I18nLang, key string, namespace string) string
+	getDicts() map[language.I18nLang]dict
 	getDict(lang language.I18nLang) (dict, error)
 }
 
 type I18nParser interface {
-	parse(fpath string) (*I18nDict, error)
+	// root is the language directory in fsys, the namespace of the file is relative to it
+	parse(fsys fs.FS, root string, fpath string) (*I18nDict, error)
 }
@@ -1,6 +1,7 @@
 package i18n
 
 import (
+	"io/fs"
 	"io/ioutil"
 	"path"
 	"strings"
@@ -39,6 +40,57 @@ func ReadAllPath(dir string, s []string, fileType string) ([]string, error) {
 	return s, nil
 }
 
+/**
+* recursively read all file path in fsys that matches the file type
+ */
+func ReadAllFSPath(fsys fs.FS, dir string, s []string, fileType string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return s, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			s, err = ReadAllFSPath(fsys, path.Join(dir, name), s, fileType)
+			if err != nil {
+				return s, err
+			}
+		} else if hasExtension(name, fileType) {
+			s = append(s, path.Join(dir, name))
+		}
+	}
+	return s, nil
+}
+
+// fileExtensions lists the file extensions of a file type, without the dot
+func fileExtensions(fileType string) []string {
+	if format, err := LookupFormat(fileType); err == nil {
+		return format.Extensions()
+	}
+	return []string{strings.ToLower(fileType)}
+}
+
+func hasExtension(name string, fileType string) bool {
+	ext := strings.TrimPrefix(path.Ext(name), ".")
+	for _, ft := range fileExtensions(fileType) {
+		if ext == ft {
+			return true
+		}
+	}
+	return false
+}
+
+// fileNamespace returns the namespace of a translation file relative to root, e.g. en/foo/bar.json -> en.foo.bar
+func fileNamespace(fpath string, root string, fileType string, splitter string) string {
+	if hasExtension(fpath, fileType) {
+		fpath = strings.TrimSuffix(fpath, path.Ext(fpath))
+	}
+	if root != "." && root != "" {
+		fpath = strings.TrimPrefix(fpath, root+"/")
+	}
+	return convert(fpath, splitter)
+}
+
 func GetNamespace(fulld string, parentd string, splitter string) string {
 	// normalize the path format
 	fulld = cleanPath(fulld)
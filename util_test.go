@@ -0,0 +1,80 @@
+package i18n
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestReadAllFSPath(t *testing.T) {
+	fsys := fstest.MapFS{
+		"i18n/en.json":         {Data: []byte(`{}`)},
+		"i18n/en/app.json":     {Data: []byte(`{}`)},
+		"i18n/en/app/sub.json": {Data: []byte(`{}`)},
+		"i18n/en/app.yaml":     {Data: []byte(``)},
+		"i18n/README":          {Data: []byte(``)},
+	}
+	got, err := ReadAllFSPath(fsys, "i18n", nil, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the entries are read in directory order
+	want := []string{"i18n/en/app/sub.json", "i18n/en/app.json", "i18n/en.json"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadAllFSPath() = %v, want %v", got, want)
+	}
+	if _, err := ReadAllFSPath(fsys, "missing", nil, "json"); err == nil {
+		t.Errorf("ReadAllFSPath(missing) succeeded")
+	}
+}
+
+func TestFileNamespace(t *testing.T) {
+	tests := []struct {
+		fpath    string
+		root     string
+		splitter string
+		want     string
+	}{
+		{"i18n/en.json", "i18n", ".", "en"},
+		{"i18n/en/app.json", "i18n", ".", "en.app"},
+		{"i18n/en/app/sub.json", "i18n", "_", "en_app_sub"},
+		{"en/app.json", ".", ".", "en.app"},
+	}
+	for _, tt := range tests {
+		if got := fileNamespace(tt.fpath, tt.root, "json", tt.splitter); got != tt.want {
+			t.Errorf("fileNamespace(%v, %v) = %v, want %v", tt.fpath, tt.root, got, tt.want)
+		}
+	}
+}
+
+func TestSetFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"i18n/en.json": {Data: []byte(`{"language":"en","dict":{"hello":"Hello"}}`)},
+		"i18n/ko.json": {Data: []byte(`{"language":"ko","dict":{"hello":"안녕하세요"}}`)},
+	}
+	opts := NewI18nOpts()
+	opts.SetEnableLangs("en,ko")
+	opts.SetTargetLang("en")
+	opts.SetFS(fsys)
+	opts.SetLanguageDir("i18n")
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	b := NewBundle(opts, log)
+	if err := b.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Trans("hello"); got != "Hello" {
+		t.Errorf("Trans(hello) = %v, want Hello", got)
+	}
+	if got := b.NewLocalizer("ko").Trans("hello"); got != "안녕하세요" {
+		t.Errorf("ko Trans(hello) = %v, want 안녕하세요", got)
+	}
+
+	opts.SetLanguageDir("missing")
+	if err := NewBundle(opts, log).Load(); err == nil {
+		t.Errorf("Load() of a missing directory succeeded")
+	}
+}
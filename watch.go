@@ -0,0 +1,74 @@
+package i18n
+
+import (
+	"context"
+	"time"
+)
+
+// defaultWatchInterval is used by Watch for a zero or negative interval
+const defaultWatchInterval = 2 * time.Second
+
+// ReloadEvent reports a reload triggered by Watch
+type ReloadEvent struct {
+	Time time.Time
+	// Files are the added, modified or removed files
+	Files []string
+	// Err is set if a file failed to parse, the previous catalog is kept in that case
+	Err error
+}
+
+// Reload re-reads the translation files and swaps in the new catalog
+func (b *Bundle) Reload() error {
+	return b.loader.reload()
+}
+
+/**
+* Watch polls the translation files every interval until ctx is done,
+* changed files are parsed again and swapped in without blocking the lookups,
+* the returned channel reports every reload and is closed once watching stops,
+* events are dropped if the channel is not drained,
+* the source layers notifying their changes are fetched again on the next tick after a change,
+* a zero or negative interval polls every two seconds
+**/
+func (b *Bundle) Watch(ctx context.Context, interval time.Duration) <-chan ReloadEvent {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	events := make(chan ReloadEvent, 16)
+	for i, layer := range b.opts.loadLayers() {
+		source, ok := layer.Source.(WatchableSource)
+		if !ok {
+			continue
+		}
+		go func(i int, changes <-chan struct{}) {
+			for range changes {
+				b.loader.markDirty(i)
+			}
+		}(i, source.Changes(ctx))
+	}
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			files, err := b.loader.refresh(false)
+			if len(files) == 0 && err == nil {
+				continue
+			}
+			if err != nil {
+				b.log.Errorf("Failed to reload trans data, error: %v", err)
+			}
+			select {
+			case events <- ReloadEvent{Time: time.Now(), Files: files, Err: err}:
+			default:
+				b.log.Warn("Reload event dropped, the event channel is full")
+			}
+		}
+	}()
+	return events
+}
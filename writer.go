@@ -1,22 +1,30 @@
 package i18n
 
 import (
-	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
 	"strings"
 	"sync"
+
+	"github.com/yaou-li/go-i18n/language"
 )
 
 /**
 * extract writer
-* flush() write all the cached data into json file
+* flush() write all the cached data into the files of the file type
 **/
 type I18nWriter interface {
 	Append(namespace string, key string) error
+	AppendPlural(namespace string, key string) error
+	AppendReference(namespace string, key string, ref string) error
 	Flush() error
 	WriteJSON(namespace string, dict *I18nDict) error
+	WriteFormat(fileType string, namespace string, dict *I18nDict) error
+	Write(namespace string, dict *I18nDict) error
+	Export(name string, dir string) error
+	Import(name string, fpath string) error
 }
 
 func NewWriter(opts *I18nOpts, dicts map[string]*I18nDict) I18nWriter {
@@ -54,23 +62,100 @@ func (w *writer) Append(namespace string, key string) error {
 	return nil
 }
 
+// AppendPlural records a key used with the plural api, Flush writes the plural skeleton of each language
+func (w *writer) AppendPlural(namespace string, key string) error {
+	if namespace == "" {
+		if w.opts.enableNamespace {
+			return nil
+		}
+		namespace = "index"
+	}
+	w.Lock()
+	defer w.Unlock()
+	if _, ok := w.ndicts[namespace]; !ok {
+		w.ndicts[namespace] = &I18nDict{
+			Dict:   make(dict),
+			Plural: make(pluralDict),
+		}
+	}
+	if w.ndicts[namespace].Plural == nil {
+		w.ndicts[namespace].Plural = make(pluralDict)
+	}
+	if _, ok := w.ndicts[namespace].Plural[key]; !ok {
+		w.ndicts[namespace].Plural[key] = make(plural)
+	}
+	return nil
+}
+
+// AppendReference records where a key is used, as file:line
+func (w *writer) AppendReference(namespace string, key string, ref string) error {
+	if namespace == "" {
+		if w.opts.enableNamespace {
+			return nil
+		}
+		namespace = "index"
+	}
+	w.Lock()
+	defer w.Unlock()
+	if _, ok := w.ndicts[namespace]; !ok {
+		w.ndicts[namespace] = &I18nDict{
+			Dict: make(dict),
+		}
+	}
+	if w.ndicts[namespace].References == nil {
+		w.ndicts[namespace].References = make(map[string][]string)
+	}
+	for _, r := range w.ndicts[namespace].References[key] {
+		if r == ref {
+			return nil
+		}
+	}
+	w.ndicts[namespace].References[key] = append(w.ndicts[namespace].References[key], ref)
+	return nil
+}
+
+/**
+* Flush writes the dict of each enabled language,
+* the po file type also writes a pot template of each namespace
+**/
 func (w *writer) Flush() error {
+	if IsCompiled(w.opts.fileType) {
+		return fmt.Errorf("Unsupported file type for extraction: %v, it is compiled from the catalogs of another type", w.opts.fileType)
+	}
 	w.Lock()
 	defer w.Unlock()
 	for _, lang := range w.opts.langs {
 		for namespace, dict := range w.ndicts {
 			ndict := dict.Clone()
-			namespace = strings.Join([]string{lang.Shortcut(), namespace}, ".")
+			namespace = strings.Join([]string{lang.Shortcut(), namespace}, w.opts.splitter)
 			ndict.Lang = lang.Shortcut()
+			for key := range ndict.Plural {
+				for _, category := range lang.PluralCategories() {
+					ndict.Plural[key][category] = ""
+				}
+				// a plural key replaces the plain string
+				delete(ndict.Dict, key)
+			}
 			if _, ok := w.odicts[namespace]; ok {
 				ndict.Overwrite(w.odicts[namespace])
 			}
 			if w.opts.enableNamespace {
 				ndict.Namespace = namespace
 			}
-			switch strings.ToUpper(w.opts.fileType) {
-			case "JSON":
-				if err := w.WriteJSON(namespace, ndict); err != nil {
+			if err := w.Write(namespace, ndict); err != nil {
+				return err
+			}
+		}
+	}
+	// formats with templates write one untranslated template for each namespace
+	if format, err := LookupFormat(w.opts.fileType); err == nil {
+		if template, ok := format.(TemplateFormat); ok {
+			for namespace, dict := range w.ndicts {
+				data, err := template.EncodeTemplate(dict)
+				if err != nil {
+					return err
+				}
+				if err := w.write(namespace, template.TemplateExtension(), data); err != nil {
 					return err
 				}
 			}
@@ -79,17 +164,120 @@ func (w *writer) Flush() error {
 	return nil
 }
 
+// Write writes the dict in the file type of the options
+func (w *writer) Write(namespace string, dict *I18nDict) error {
+	return w.WriteFormat(w.opts.fileType, namespace, dict)
+}
+
+// WriteFormat writes the dict with the format registered for the file type
+func (w *writer) WriteFormat(fileType string, namespace string, dict *I18nDict) error {
+	format, err := LookupFormat(fileType)
+	if err != nil {
+		return err
+	}
+	if nf, ok := format.(NestedFormat); ok && w.opts.nestedKeys {
+		format = nf.Nested(w.opts.splitter)
+	}
+	data, err := format.Encode(dict)
+	if err != nil {
+		return err
+	}
+	return w.write(namespace, format.Extensions()[0], data)
+}
+
+// Export writes the existing catalogs with the exchange format registered for the name
+func (w *writer) Export(name string, dir string) error {
+	format, err := LookupExchangeFormat(name)
+	if err != nil {
+		return err
+	}
+	w.Lock()
+	defer w.Unlock()
+	return format.Export(w.opts, w.catalogs(), dir)
+}
+
+// Import merges the translations read with the exchange format registered for the name into the catalogs
+func (w *writer) Import(name string, fpath string) error {
+	format, err := LookupExchangeFormat(name)
+	if err != nil {
+		return err
+	}
+	w.Lock()
+	defer w.Unlock()
+	catalogs, err := format.Import(w.opts, w.catalogs(), fpath)
+	if err != nil {
+		return err
+	}
+	return w.importCatalogs(catalogs)
+}
+
+// catalogs returns the existing catalogs of the enabled languages
+func (w *writer) catalogs() Catalogs {
+	res := make(Catalogs)
+	for _, lang := range w.opts.langs {
+		res[lang] = w.langDicts(lang)
+	}
+	return res
+}
+
+func (w *writer) importCatalogs(catalogs Catalogs) error {
+	for lang, dicts := range catalogs {
+		for namespace, d := range dicts {
+			d.Lang = lang.Shortcut()
+			if err := w.importDict(lang.Shortcut()+w.opts.splitter+namespace, d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/**
+* importDict merges the translations into the catalog of namespace and writes it,
+* the imported translations are reviewed so their fuzzy flags are dropped
+**/
+func (w *writer) importDict(namespace string, d *I18nDict) error {
+	ndict := d
+	if odict, ok := w.odicts[namespace]; ok {
+		ndict = odict.Clone()
+		for key := range d.Dict {
+			delete(ndict.Flags, key)
+		}
+		for key := range d.Plural {
+			delete(ndict.Flags, key)
+		}
+		ndict.Overwrite(d)
+	}
+	if w.opts.enableNamespace {
+		ndict.Namespace = namespace
+	}
+	w.odicts[namespace] = ndict
+	return w.Write(namespace, ndict)
+}
+
+// langDicts returns the catalogs of lang, keyed by namespace without the language
+func (w *writer) langDicts(lang language.I18nLang) map[string]*I18nDict {
+	res := make(map[string]*I18nDict)
+	prefix := lang.Shortcut() + w.opts.splitter
+	for namespace, d := range w.odicts {
+		if strings.HasPrefix(namespace, prefix) {
+			res[strings.TrimPrefix(namespace, prefix)] = d
+		}
+	}
+	return res
+}
+
 func (w *writer) WriteJSON(namespace string, dict *I18nDict) error {
+	return w.WriteFormat("json", namespace, dict)
+}
+
+func (w *writer) write(namespace string, ext string, data []byte) error {
 	p := path.Join(strings.Split(namespace, w.opts.splitter)...)
-	p = p + ".json"
+	p = p + "." + ext
 	dir := path.Dir(p)
 	// make sure the directory already exists
 	if err := os.MkdirAll(path.Join(w.opts.dir, dir), os.ModePerm); err != nil {
 		return err
 	}
-	if data, err := json.MarshalIndent(dict, "", "    "); err != nil {
-		return err
-	} else {
-		return ioutil.WriteFile(path.Join(w.opts.dir, p), data, os.ModePerm)
-	}
+	return ioutil.WriteFile(path.Join(w.opts.dir, p), data, os.ModePerm)
 }
@@ -0,0 +1,29 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFlushSplitter(t *testing.T) {
+	dir := t.TempDir()
+	opts := NewI18nOpts()
+	opts.SetEnableLangs("en,ko")
+	opts.SetTargetLang("en")
+	opts.SetLanguageDir(dir)
+	opts.SetSplitter("_")
+	opts.SetEnableNamespace(true)
+	w := NewWriter(opts, make(map[string]*I18nDict))
+	if err := w.Append("app_home", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for _, fpath := range []string{"en/app/home.json", "ko/app/home.json"} {
+		if _, err := os.Stat(filepath.Join(dir, fpath)); err != nil {
+			t.Errorf("Flush() did not write %v: %v", fpath, err)
+		}
+	}
+}
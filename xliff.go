@@ -0,0 +1,503 @@
+package i18n
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+const (
+	XLIFF12 = "1.2"
+	XLIFF20 = "2.0"
+)
+
+const (
+	xliff12Namespace = "urn:oasis:names:tc:xliff:document:1.2"
+	xliff20Namespace = "urn:oasis:names:tc:xliff:document:2.0"
+	// restype and type of the groups holding the forms of a plural key
+	xliff12PluralType = "x-gettext-plurals"
+	xliff20PluralType = "i18n:plural"
+	// from and category of the notes holding the comments of the source and of the target language
+	xliffDeveloper  = "developer"
+	xliffTranslator = "translator"
+)
+
+/**
+* xliff 1.2, the keys are kept in resname since ids are not meant to be read,
+* the forms of a plural key are grouped
+**/
+type xliff12 struct {
+	XMLName xml.Name      `xml:"xliff"`
+	Xmlns   string        `xml:"xmlns,attr,omitempty"`
+	Version string        `xml:"version,attr"`
+	Files   []xliff12File `xml:"file"`
+}
+
+type xliff12File struct {
+	Original   string         `xml:"original,attr"`
+	SourceLang string         `xml:"source-language,attr"`
+	TargetLang string         `xml:"target-language,attr,omitempty"`
+	Datatype   string         `xml:"datatype,attr"`
+	Units      []xliff12Unit  `xml:"body>trans-unit"`
+	Groups     []xliff12Group `xml:"body>group"`
+}
+
+type xliff12Group struct {
+	Id      string        `xml:"id,attr"`
+	Resname string        `xml:"resname,attr,omitempty"`
+	Restype string        `xml:"restype,attr,omitempty"`
+	Units   []xliff12Unit `xml:"trans-unit"`
+}
+
+type xliff12Unit struct {
+	Id      string        `xml:"id,attr"`
+	Resname string        `xml:"resname,attr,omitempty"`
+	Source  string        `xml:"source"`
+	Target  xliff12Target `xml:"target"`
+	Notes   []xliff12Note `xml:"note"`
+}
+
+// xliff12Note annotates the source or the target, the translator notes annotate the target
+type xliff12Note struct {
+	From      string `xml:"from,attr,omitempty"`
+	Annotates string `xml:"annotates,attr,omitempty"`
+	Value     string `xml:",chardata"`
+}
+
+type xliff12Target struct {
+	State string `xml:"state,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+// xliff 2.0, the keys are kept in the name attributes
+type xliff20 struct {
+	XMLName xml.Name      `xml:"xliff"`
+	Xmlns   string        `xml:"xmlns,attr,omitempty"`
+	Version string        `xml:"version,attr"`
+	SrcLang string        `xml:"srcLang,attr"`
+	TrgLang string        `xml:"trgLang,attr,omitempty"`
+	Files   []xliff20File `xml:"file"`
+}
+
+type xliff20File struct {
+	Id     string         `xml:"id,attr"`
+	Units  []xliff20Unit  `xml:"unit"`
+	Groups []xliff20Group `xml:"group"`
+}
+
+type xliff20Group struct {
+	Id    string        `xml:"id,attr"`
+	Name  string        `xml:"name,attr,omitempty"`
+	Type  string        `xml:"type,attr,omitempty"`
+	Units []xliff20Unit `xml:"unit"`
+}
+
+type xliff20Unit struct {
+	Id      string         `xml:"id,attr"`
+	Name    string         `xml:"name,attr,omitempty"`
+	Notes   *xliff20Notes  `xml:"notes,omitempty"`
+	Segment xliff20Segment `xml:"segment"`
+}
+
+type xliff20Notes struct {
+	Notes []xliff20Note `xml:"note"`
+}
+
+// xliff20Note applies to the source or the target, the translator notes apply to the target
+type xliff20Note struct {
+	Category  string `xml:"category,attr,omitempty"`
+	AppliesTo string `xml:"appliesTo,attr,omitempty"`
+	Value     string `xml:",chardata"`
+}
+
+type xliff20Segment struct {
+	State  string `xml:"state,attr,omitempty"`
+	Source string `xml:"source"`
+	Target string `xml:"target"`
+}
+
+// targetNotes returns the notes on the target, the notes on the source are the comments of the source language
+func (u xliff12Unit) targetNotes() []string {
+	var notes []string
+	for _, note := range u.Notes {
+		if note.Annotates == "target" || note.Annotates == "" && note.From == xliffTranslator {
+			notes = append(notes, note.Value)
+		}
+	}
+	return notes
+}
+
+func (u xliff20Unit) targetNotes() []string {
+	if u.Notes == nil {
+		return nil
+	}
+	var notes []string
+	for _, note := range u.Notes.Notes {
+		if note.AppliesTo == "target" || note.AppliesTo == "" && note.Category == xliffTranslator {
+			notes = append(notes, note.Value)
+		}
+	}
+	return notes
+}
+
+// xliffUnit is a source and target pair of either version
+type xliffUnit struct {
+	key    string
+	source string
+	target string
+	// comments of the source and of the target language
+	srcNote string
+	trgNote string
+	fuzzy   bool
+}
+
+func (u xliffUnit) xliff12Notes() []xliff12Note {
+	var notes []xliff12Note
+	if u.srcNote != "" {
+		notes = append(notes, xliff12Note{From: xliffDeveloper, Annotates: "source", Value: u.srcNote})
+	}
+	if u.trgNote != "" {
+		notes = append(notes, xliff12Note{From: xliffTranslator, Annotates: "target", Value: u.trgNote})
+	}
+	return notes
+}
+
+func (u xliffUnit) xliff20Notes() *xliff20Notes {
+	var notes []xliff20Note
+	if u.srcNote != "" {
+		notes = append(notes, xliff20Note{Category: xliffDeveloper, AppliesTo: "source", Value: u.srcNote})
+	}
+	if u.trgNote != "" {
+		notes = append(notes, xliff20Note{Category: xliffTranslator, AppliesTo: "target", Value: u.trgNote})
+	}
+	if len(notes) == 0 {
+		return nil
+	}
+	return &xliff20Notes{notes}
+}
+
+// xliffEntry is a key, a plural key holds one unit per category of the target language
+type xliffEntry struct {
+	key    string
+	plural bool
+	units  []xliffUnit
+}
+
+/**
+* xliffEntries pairs the source and the target dict of a namespace,
+* the key is the source text when the source language has no translation
+**/
+func xliffEntries(src *I18nDict, trg *I18nDict, trgLang language.I18nLang) []xliffEntry {
+	if src == nil {
+		src = &I18nDict{}
+	}
+	if trg == nil {
+		trg = &I18nDict{}
+	}
+	keys := make(map[string]bool)
+	for _, d := range []*I18nDict{src, trg} {
+		for key := range d.Dict {
+			keys[key] = true
+		}
+		for key := range d.Plural {
+			keys[key] = true
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	var res []xliffEntry
+	for _, key := range sorted {
+		srcNote, trgNote := src.Comments[key], trg.Comments[key]
+		srcForms, srcPlural := src.Plural[key]
+		trgForms, trgPlural := trg.Plural[key]
+		if !srcPlural && !trgPlural {
+			source := src.Dict[key]
+			if source == "" {
+				source = key
+			}
+			res = append(res, xliffEntry{key: key, units: []xliffUnit{{
+				key:     key,
+				source:  source,
+				target:  trg.Dict[key],
+				srcNote: srcNote,
+				trgNote: trgNote,
+				fuzzy:   trg.IsFuzzy(key),
+			}}})
+			continue
+		}
+		entry := xliffEntry{key: key, plural: true}
+		for _, category := range trgLang.PluralCategories() {
+			source, ok := srcForms.get(category)
+			if !ok {
+				source = key
+			}
+			entry.units = append(entry.units, xliffUnit{
+				key:     string(category),
+				source:  source,
+				target:  trgForms[category],
+				srcNote: srcNote,
+				trgNote: trgNote,
+				fuzzy:   trg.IsFuzzy(key),
+			})
+		}
+		res = append(res, entry)
+	}
+	return res
+}
+
+func xliff12State(u xliffUnit) string {
+	switch {
+	case u.target == "":
+		return "new"
+	case u.fuzzy:
+		return "needs-review-translation"
+	default:
+		return "translated"
+	}
+}
+
+func xliff20State(u xliffUnit) string {
+	if u.target == "" || u.fuzzy {
+		return "initial"
+	}
+	return "translated"
+}
+
+/**
+* EncodeXLIFF exports the source and the target dicts, keyed by namespace without the language,
+* each namespace is a <file> and each plural key a <group>
+**/
+func EncodeXLIFF(version string, srcLang language.I18nLang, trgLang language.I18nLang, srcs map[string]*I18nDict, trgs map[string]*I18nDict) ([]byte, error) {
+	namespaces := make(map[string]bool)
+	for namespace := range srcs {
+		namespaces[namespace] = true
+	}
+	for namespace := range trgs {
+		namespaces[namespace] = true
+	}
+	sorted := make([]string, 0, len(namespaces))
+	for namespace := range namespaces {
+		sorted = append(sorted, namespace)
+	}
+	sort.Strings(sorted)
+
+	var doc interface{}
+	switch version {
+	case XLIFF12:
+		x := &xliff12{Xmlns: xliff12Namespace, Version: XLIFF12}
+		for _, namespace := range sorted {
+			file := xliff12File{
+				Original:   namespace,
+				SourceLang: srcLang.Shortcut(),
+				TargetLang: trgLang.Shortcut(),
+				Datatype:   "plaintext",
+			}
+			for i, entry := range xliffEntries(srcs[namespace], trgs[namespace], trgLang) {
+				id := strconv.Itoa(i + 1)
+				var units []xliff12Unit
+				for j, u := range entry.units {
+					unitId := id
+					if entry.plural {
+						unitId = id + "[" + strconv.Itoa(j) + "]"
+					}
+					units = append(units, xliff12Unit{
+						Id:      unitId,
+						Resname: u.key,
+						Source:  u.source,
+						Target:  xliff12Target{State: xliff12State(u), Value: u.target},
+						Notes:   u.xliff12Notes(),
+					})
+				}
+				if entry.plural {
+					file.Groups = append(file.Groups, xliff12Group{Id: id, Resname: entry.key, Restype: xliff12PluralType, Units: units})
+				} else {
+					file.Units = append(file.Units, units...)
+				}
+			}
+			x.Files = append(x.Files, file)
+		}
+		doc = x
+	case XLIFF20:
+		x := &xliff20{Xmlns: xliff20Namespace, Version: XLIFF20, SrcLang: srcLang.Shortcut(), TrgLang: trgLang.Shortcut()}
+		for _, namespace := range sorted {
+			file := xliff20File{Id: namespace}
+			for i, entry := range xliffEntries(srcs[namespace], trgs[namespace], trgLang) {
+				id := "u" + strconv.Itoa(i+1)
+				var units []xliff20Unit
+				for j, u := range entry.units {
+					unitId := id
+					if entry.plural {
+						unitId = id + "-" + strconv.Itoa(j)
+					}
+					unit := xliff20Unit{
+						Id:      unitId,
+						Name:    u.key,
+						Notes:   u.xliff20Notes(),
+						Segment: xliff20Segment{State: xliff20State(u), Source: u.source, Target: u.target},
+					}
+					units = append(units, unit)
+				}
+				if entry.plural {
+					file.Groups = append(file.Groups, xliff20Group{Id: id, Name: entry.key, Type: xliff20PluralType, Units: units})
+				} else {
+					file.Units = append(file.Units, units...)
+				}
+			}
+			x.Files = append(x.Files, file)
+		}
+		doc = x
+	default:
+		return nil, fmt.Errorf("Unsupported xliff version: %v", version)
+	}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+/**
+* DecodeXLIFF imports the targets of a translated xliff document,
+* it returns the target language and the dicts keyed by namespace without the language,
+* untranslated units are left out so that importing never clears a translation,
+* the notes on the target become the comments, the other notes are left alone
+**/
+func DecodeXLIFF(data []byte) (string, map[string]*I18nDict, error) {
+	var head struct {
+		Version string `xml:"version,attr"`
+	}
+	if err := xml.Unmarshal(data, &head); err != nil {
+		return "", nil, err
+	}
+	var (
+		lang  string
+		dicts = make(map[string]*I18nDict)
+	)
+	add := func(namespace string, key string, category string, target string, notes []string, fuzzy bool) {
+		if target == "" {
+			return
+		}
+		d, ok := dicts[namespace]
+		if !ok {
+			d = &I18nDict{
+				Dict:     make(dict),
+				Plural:   make(pluralDict),
+				Comments: make(map[string]string),
+				Flags:    make(map[string][]string),
+			}
+			dicts[namespace] = d
+		}
+		if category != "" {
+			if _, ok := d.Plural[key]; !ok {
+				d.Plural[key] = make(plural)
+			}
+			d.Plural[key][language.PluralCategory(category)] = target
+		} else {
+			d.Dict[key] = target
+		}
+		// only the notes on the target are comments of the target language
+		if len(notes) > 0 {
+			d.Comments[key] = strings.Join(notes, "\n")
+		}
+		if fuzzy {
+			d.Flags[key] = []string{"fuzzy"}
+		}
+	}
+	switch head.Version {
+	case XLIFF12:
+		var x xliff12
+		if err := xml.Unmarshal(data, &x); err != nil {
+			return "", nil, err
+		}
+		for _, file := range x.Files {
+			// every file must be translated into the same language
+			if file.TargetLang != "" && lang != "" && !sameLang(file.TargetLang, lang) {
+				return "", nil, fmt.Errorf("Mixed xliff target languages: %v, %v", lang, file.TargetLang)
+			}
+			if lang == "" {
+				lang = file.TargetLang
+			}
+			for _, u := range file.Units {
+				key := u.Resname
+				if key == "" {
+					key = u.Id
+				}
+				add(file.Original, key, "", u.Target.Value, u.targetNotes(), xliff12Fuzzy(u.Target.State))
+			}
+			for _, g := range file.Groups {
+				key := g.Resname
+				if key == "" {
+					key = g.Id
+				}
+				for _, u := range g.Units {
+					if !language.IsPluralCategory(u.Resname) {
+						return "", nil, fmt.Errorf("Invalid plural category: %v of key: %v", u.Resname, key)
+					}
+					add(file.Original, key, u.Resname, u.Target.Value, u.targetNotes(), xliff12Fuzzy(u.Target.State))
+				}
+			}
+		}
+	case XLIFF20:
+		var x xliff20
+		if err := xml.Unmarshal(data, &x); err != nil {
+			return "", nil, err
+		}
+		lang = x.TrgLang
+		for _, file := range x.Files {
+			for _, u := range file.Units {
+				key := u.Name
+				if key == "" {
+					key = u.Id
+				}
+				add(file.Id, key, "", u.Segment.Target, u.targetNotes(), u.Segment.State == "initial")
+			}
+			for _, g := range file.Groups {
+				key := g.Name
+				if key == "" {
+					key = g.Id
+				}
+				for _, u := range g.Units {
+					if !language.IsPluralCategory(u.Name) {
+						return "", nil, fmt.Errorf("Invalid plural category: %v of key: %v", u.Name, key)
+					}
+					add(file.Id, key, u.Name, u.Segment.Target, u.targetNotes(), u.Segment.State == "initial")
+				}
+			}
+		}
+	default:
+		return "", nil, fmt.Errorf("Unsupported xliff version: %v", head.Version)
+	}
+	if lang == "" {
+		return "", nil, fmt.Errorf("Missing xliff target language")
+	}
+	if language.IsSupported(lang) {
+		lang = language.GetLang(lang).Shortcut()
+	}
+	for _, d := range dicts {
+		d.Lang = lang
+	}
+	return lang, dicts, nil
+}
+
+// sameLang compares two spellings of a language tag
+func sameLang(a string, b string) bool {
+	if language.IsSupported(a) && language.IsSupported(b) {
+		return language.GetLang(a) == language.GetLang(b)
+	}
+	return strings.EqualFold(a, b)
+}
+
+func xliff12Fuzzy(state string) bool {
+	switch state {
+	case "needs-review-translation", "needs-review-l10n", "needs-review-adaptation", "needs-translation", "needs-l10n", "needs-adaptation":
+		return true
+	}
+	return false
+}
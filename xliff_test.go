@@ -0,0 +1,116 @@
+package i18n
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+func TestXLIFFRoundTrip(t *testing.T) {
+	srcs := map[string]*I18nDict{
+		"app": {
+			Lang: "en",
+			Dict: dict{"hello": "Hello", "markup": "<b>Bold</b> & co", "new": "New"},
+			Plural: pluralDict{
+				"files": {language.PluralOne: "%d file", language.PluralOther: "%d files"},
+			},
+			Comments: map[string]string{"hello": "greeting"},
+		},
+		"app.menu": {Lang: "en", Dict: dict{"open": "Open"}},
+	}
+	trgs := map[string]*I18nDict{
+		"app": {
+			Lang: "ru",
+			Dict: dict{"hello": "Привет", "markup": "<b>Жирный</b> & ко", "draft": "Черновик"},
+			Plural: pluralDict{
+				"files": {language.PluralOne: "%d файл", language.PluralFew: "%d файла", language.PluralMany: "%d файлов"},
+			},
+			Comments: map[string]string{"hello": "informal\non purpose"},
+			Flags:    map[string][]string{"draft": {"fuzzy"}},
+		},
+	}
+	for _, version := range []string{XLIFF12, XLIFF20} {
+		data, err := EncodeXLIFF(version, language.English, language.Russian, srcs, trgs)
+		if err != nil {
+			t.Fatalf("EncodeXLIFF(%v) error: %v", version, err)
+		}
+		lang, dicts, err := DecodeXLIFF(data)
+		if err != nil {
+			t.Fatalf("DecodeXLIFF(%v) error: %v\n%s", version, err, data)
+		}
+		if lang != "ru" {
+			t.Errorf("%v: lang = %v, want ru", version, lang)
+		}
+		// the untranslated units are left out
+		if _, ok := dicts["app.menu"]; ok || len(dicts) != 1 {
+			t.Errorf("%v: namespaces = %v, want only app", version, dicts)
+			continue
+		}
+		got := dicts["app"]
+		assertDict(t, got, &I18nDict{
+			Lang: "ru",
+			Dict: trgs["app"].Dict,
+			Plural: pluralDict{
+				"files": trgs["app"].Plural["files"],
+			},
+			// the notes of the source are not comments of the target
+			Comments: map[string]string{"hello": "informal\non purpose"},
+		})
+		if !got.IsFuzzy("draft") || got.IsFuzzy("hello") {
+			t.Errorf("%v: IsFuzzy(draft), IsFuzzy(hello) = %v, %v, want true, false", version, got.IsFuzzy("draft"), got.IsFuzzy("hello"))
+		}
+	}
+	if _, err := EncodeXLIFF("3.0", language.English, language.Russian, srcs, trgs); err == nil {
+		t.Errorf("EncodeXLIFF(3.0) succeeded")
+	}
+}
+
+func TestXLIFFEncodeSource(t *testing.T) {
+	// the key is the source text without a translation in the source language
+	data, err := EncodeXLIFF(XLIFF12, language.English, language.Japanese, map[string]*I18nDict{
+		"index": {Lang: "en", Dict: dict{"Save changes": ""}, Plural: pluralDict{"%d items": {language.PluralOther: ""}}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<source>Save changes</source>`,
+		`<group id="1" resname="%d items" restype="x-gettext-plurals">`,
+		`<trans-unit id="1[0]" resname="other">`,
+		`<source>%d items</source>`,
+		`<target state="new"></target>`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("EncodeXLIFF() does not contain %v:\n%s", want, data)
+		}
+	}
+}
+
+func TestDecodeXLIFFInvalid(t *testing.T) {
+	tests := []string{
+		`<xliff version="3.0"></xliff>`,
+		`<xliff version="1.2"><file original="app" source-language="en"><body></body></file></xliff>`,
+		`<xliff version="1.2">` +
+			`<file original="a" target-language="ru"><body><trans-unit id="1"><source>a</source><target>а</target></trans-unit></body></file>` +
+			`<file original="b" target-language="ja"><body><trans-unit id="1"><source>b</source><target>b</target></trans-unit></body></file>` +
+			`</xliff>`,
+		`<xliff version="2.0" trgLang="ru"><file id="app"><group id="u1" name="files"><unit id="u1-0" name="several">` +
+			`<segment><source>a</source><target>b</target></segment></unit></group></file></xliff>`,
+		`<xliff version="1.2"`,
+	}
+	for _, data := range tests {
+		if _, _, err := DecodeXLIFF([]byte(data)); err == nil {
+			t.Errorf("DecodeXLIFF(%v) succeeded", data)
+		}
+	}
+	lang, dicts, err := DecodeXLIFF([]byte(`<xliff version="2.0" trgLang="zh_TW"><file id="app"><unit id="u1" name="hello">` +
+		`<segment state="translated"><source>Hello</source><target>你好</target></segment></unit></file></xliff>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (dict{"hello": "你好"}); lang != "zh-Hant" || !reflect.DeepEqual(dicts["app"].Dict, want) {
+		t.Errorf("DecodeXLIFF() = %v, %v, want zh-Hant, %v", lang, dicts["app"].Dict, want)
+	}
+}
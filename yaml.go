@@ -0,0 +1,244 @@
+package i18n
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yaou-li/go-i18n/language"
+	"gopkg.in/yaml.v3"
+)
+
+/**
+* yamlFormat reads the same layout as the json files:
+* language: en
+* dict:
+*   menu:
+*     # shown in the top bar
+*     title: Files
+*   files:
+*     one: "%d file"
+*     other: "%d files"
+* nested maps are flattened with the splitter, "menu.title" above,
+* a map holding only plural categories with "other" among them is a plural entry,
+* the namespace falls back to the one derived from the path
+**/
+type yamlFormat struct {
+	// the extension written, yaml or yml
+	ext string
+}
+
+func (yf yamlFormat) Extensions() []string {
+	if yf.ext == "yml" {
+		return []string{"yml", "yaml"}
+	}
+	return []string{"yaml", "yml"}
+}
+
+func (yamlFormat) Decode(data []byte, file FormatFile) (*I18nDict, error) {
+	d, err := decodeYAML(data, file.Splitter)
+	if err != nil {
+		return nil, err
+	}
+	if d.Namespace == "" {
+		d.Namespace = file.Namespace
+	}
+	return d, nil
+}
+
+func (yamlFormat) Encode(dict *I18nDict) ([]byte, error) {
+	return encodeYAML(dict)
+}
+
+func decodeYAML(data []byte, splitter string) (*I18nDict, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	d := &I18nDict{
+		Dict:     make(dict),
+		Plural:   make(pluralDict),
+		Comments: make(map[string]string),
+	}
+	// empty file
+	if len(doc.Content) == 0 {
+		return d, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("Invalid yaml translation file, line %v: expect a map", root.Line)
+	}
+	// the file comment is attached to the first key unless a blank line follows it
+	comments := []string{yamlComment(doc.HeadComment)}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, val := root.Content[i], yamlValue(root.Content[i+1])
+		comments = append(comments, yamlComment(key.HeadComment))
+		switch key.Value {
+		case "language":
+			d.Lang = val.Value
+		case "namespace":
+			d.Namespace = val.Value
+		case "dict":
+			if err := flattenYAML(val, "", splitter, d); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if comment := strings.TrimSpace(strings.Join(comments, "\n")); comment != "" {
+		d.Comments[""] = comment
+	}
+	return d, nil
+}
+
+func flattenYAML(node *yaml.Node, prefix string, splitter string, d *I18nDict) error {
+	// a dict without any entry
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("Invalid yaml dict, line %v: expect a map", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, val := node.Content[i], yamlValue(node.Content[i+1])
+		fullKey := key.Value
+		if prefix != "" {
+			fullKey = prefix + splitter + key.Value
+		}
+		if comment := yamlComment(key.HeadComment); comment != "" {
+			d.Comments[fullKey] = comment
+		}
+		switch val.Kind {
+		case yaml.ScalarNode:
+			if val.Tag == "!!null" {
+				d.Dict[fullKey] = ""
+			} else {
+				d.Dict[fullKey] = val.Value
+			}
+		case yaml.MappingNode:
+			if forms, ok := yamlPlural(val); ok {
+				d.Plural[fullKey] = forms
+			} else if err := flattenYAML(val, fullKey, splitter, d); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("Invalid translation of key: %v, line %v", fullKey, val.Line)
+		}
+	}
+	return nil
+}
+
+func yamlValue(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// yamlPlural converts a map of plural categories to scalar forms, the other category is required like pluralMap
+func yamlPlural(node *yaml.Node) (plural, bool) {
+	forms := make(plural)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, val := node.Content[i], yamlValue(node.Content[i+1])
+		if !language.IsPluralCategory(key.Value) || val.Kind != yaml.ScalarNode {
+			return nil, false
+		}
+		if val.Tag == "!!null" {
+			forms[language.PluralCategory(key.Value)] = ""
+		} else {
+			forms[language.PluralCategory(key.Value)] = val.Value
+		}
+	}
+	if _, ok := forms[language.PluralOther]; !ok {
+		return nil, false
+	}
+	return forms, true
+}
+
+// yamlComment strips the comment markers, lines are kept
+func yamlComment(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "#")
+		lines[i] = strings.TrimPrefix(line, " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func yamlCommentLines(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("# "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func yamlString(val string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: val}
+	// multi-line translations are written as block scalars
+	if strings.Contains(strings.TrimRight(val, "\n"), "\n") {
+		node.Style = yaml.LiteralStyle
+	}
+	return node
+}
+
+/**
+* encodeYAML writes the keys flat and sorted, so that no key is lost to the splitter,
+* the comments are written above their keys
+**/
+func encodeYAML(d *I18nDict) ([]byte, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	root.Content = append(root.Content, yamlString("language"), yamlString(d.Lang))
+	if d.Namespace != "" {
+		root.Content = append(root.Content, yamlString("namespace"), yamlString(d.Namespace))
+	}
+	keys := make([]string, 0, len(d.Dict)+len(d.Plural))
+	for key := range d.Dict {
+		if _, ok := d.Plural[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	for key := range d.Plural {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	entries := &yaml.Node{Kind: yaml.MappingNode}
+	for _, key := range keys {
+		knode := yamlString(key)
+		knode.Style = 0
+		knode.HeadComment = yamlCommentLines(d.Comments[key])
+		if forms, ok := d.Plural[key]; ok {
+			vnode := &yaml.Node{Kind: yaml.MappingNode}
+			for _, category := range pluralOrder {
+				if form, ok := forms[category]; ok {
+					vnode.Content = append(vnode.Content, yamlString(string(category)), yamlString(form))
+				}
+			}
+			entries.Content = append(entries.Content, knode, vnode)
+		} else {
+			entries.Content = append(entries.Content, knode, yamlString(d.Dict[key]))
+		}
+	}
+	root.Content = append(root.Content, yamlString("dict"), entries)
+	doc := &yaml.Node{
+		Kind:        yaml.DocumentNode,
+		HeadComment: yamlCommentLines(d.Comments[""]),
+		Content:     []*yaml.Node{root},
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
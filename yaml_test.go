@@ -0,0 +1,100 @@
+package i18n
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yaou-li/go-i18n/language"
+)
+
+// roundTrip encodes the dict with the format and decodes it back
+func roundTrip(t *testing.T, format Format, d *I18nDict, file FormatFile) *I18nDict {
+	t.Helper()
+	data, err := format.Encode(d)
+	if err != nil {
+		t.Fatalf("Encode() error: %v", err)
+	}
+	got, err := format.Decode(data, file)
+	if err != nil {
+		t.Fatalf("Decode() error: %v\n%s", err, data)
+	}
+	return got
+}
+
+// assertDict compares the translations of two dicts, the comments too if want has any
+func assertDict(t *testing.T, got *I18nDict, want *I18nDict) {
+	t.Helper()
+	if got.Lang != want.Lang || got.Namespace != want.Namespace {
+		t.Errorf("lang, namespace = %v, %v, want %v, %v", got.Lang, got.Namespace, want.Lang, want.Namespace)
+	}
+	if (len(got.Dict) != 0 || len(want.Dict) != 0) && !reflect.DeepEqual(got.Dict, want.Dict) {
+		t.Errorf("Dict = %v, want %v", got.Dict, want.Dict)
+	}
+	if (len(got.Plural) != 0 || len(want.Plural) != 0) && !reflect.DeepEqual(got.Plural, want.Plural) {
+		t.Errorf("Plural = %v, want %v", got.Plural, want.Plural)
+	}
+	if len(want.Comments) != 0 && !reflect.DeepEqual(got.Comments, want.Comments) {
+		t.Errorf("Comments = %q, want %q", got.Comments, want.Comments)
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	d := &I18nDict{
+		Lang:      "en",
+		Namespace: "en.app",
+		Dict: dict{
+			"menu.title": "Files",
+			"colon":      "key: value",
+			"quoted":     `say "hi" # not a comment`,
+			"multiline":  "first\nsecond",
+			"empty":      "",
+			"yes":        "no",
+		},
+		Plural: pluralDict{
+			"files": {language.PluralOne: "%d file", language.PluralOther: "%d files"},
+		},
+		Comments: map[string]string{
+			"":           "app strings",
+			"menu.title": "shown in the top bar\nkeep it short",
+		},
+	}
+	got := roundTrip(t, yamlFormat{"yaml"}, d, FormatFile{Splitter: "."})
+	assertDict(t, got, d)
+}
+
+func TestYAMLDecode(t *testing.T) {
+	data := []byte(`language: ko
+dict:
+  menu:
+    # shown in the top bar
+    title: 파일
+  files:
+    one: "%d 파일"
+    other: "%d 파일"
+  # without other, the categories are plain keys
+  size:
+    one: small
+    few: medium
+`)
+	got, err := yamlFormat{"yaml"}.Decode(data, FormatFile{Namespace: "ko.app", Splitter: "_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDict(t, got, &I18nDict{
+		Lang:      "ko",
+		Namespace: "ko.app",
+		Dict:      dict{"menu_title": "파일", "size_one": "small", "size_few": "medium"},
+		Plural: pluralDict{
+			"files": {language.PluralOne: "%d 파일", language.PluralOther: "%d 파일"},
+		},
+		Comments: map[string]string{
+			"menu_title": "shown in the top bar",
+			"size":       "without other, the categories are plain keys",
+		},
+	})
+	for _, data := range []string{"- a\n- b\n", "dict: [a, b]\n", "dict:\n  key: [a]\n"} {
+		if _, err := (yamlFormat{"yaml"}).Decode([]byte(data), FormatFile{Splitter: "."}); err == nil {
+			t.Errorf("Decode(%q) succeeded", data)
+		}
+	}
+}